@@ -0,0 +1,166 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+)
+
+// Audited actions. Events are append-only; the table rejects updates and
+// deletes.
+const (
+	auditLoginSucceeded             = "login.succeeded"
+	auditLoginFailed                = "login.failed"
+	auditLoginRefused               = "login.refused"
+	auditUserUpdated                = "user.updated"
+	auditUserUpgraded               = "user.upgraded"
+	auditUserRoleChanged            = "user.role_changed"
+	auditUserSuspensionChanged      = "user.suspension_changed"
+	auditUserShadowbanChanged       = "user.shadowban_changed"
+	auditRefreshTokenRevoked        = "refresh_token.revoked"
+	auditPersonalAccessTokenRevoked = "personal_access_token.revoked"
+	auditChirpDeleted               = "chirp.deleted"
+	auditChirpModerated             = "chirp.moderated"
+	auditAdminReset                 = "admin.reset"
+)
+
+const (
+	auditTargetUser                = "user"
+	auditTargetEmail               = "email"
+	auditTargetChirp               = "chirp"
+	auditTargetRefreshToken        = "refresh_token"
+	auditTargetPersonalAccessToken = "personal_access_token"
+	auditTargetPlatform            = "platform"
+)
+
+// auditChange is one field of an event's diff. Secrets such as passwords are
+// recorded as changed without their values.
+type auditChange struct {
+	From any `json:"from,omitempty"`
+	To   any `json:"to,omitempty"`
+}
+
+type auditDiff map[string]auditChange
+
+type auditEvent struct {
+	// ActorID is nil for anonymous callers and webhooks.
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Diff       auditDiff
+}
+
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	Diff       json.RawMessage `json:"diff"`
+}
+
+// recordAudit appends an event for the request. Failing to record it is
+// logged and never fails the request, since the action already happened.
+func (cfg *apiConfig) recordAudit(r *http.Request, event auditEvent) {
+	diff := []byte("{}")
+	if len(event.Diff) > 0 {
+		marshalled, err := json.Marshal(event.Diff)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error marshalling audit diff", "action", event.Action, "error", err)
+		} else {
+			diff = marshalled
+		}
+	}
+
+	actorID := uuid.NullUUID{}
+	if event.ActorID != nil {
+		actorID = uuid.NullUUID{UUID: *event.ActorID, Valid: true}
+	}
+
+	err := cfg.db.CreateAuditEvent(r.Context(), database.CreateAuditEventParams{
+		ActorID:    actorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Ip:         cfg.clientIP(r),
+		UserAgent:  r.UserAgent(),
+		Diff:       diff,
+	})
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error recording audit event", "action", event.Action, "target_type", event.TargetType, "target_id", event.TargetID, "error", err)
+	}
+}
+
+func (cfg *apiConfig) listAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := database.ListAuditEventsParams{RowLimit: 100}
+
+	if v := query.Get("actor_id"); v != "" {
+		actorID, err := uuid.Parse(v)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid actor_id", err)
+			return
+		}
+		params.ActorID = uuid.NullUUID{UUID: actorID, Valid: true}
+	}
+	if v := query.Get("target_id"); v != "" {
+		params.TargetID = sql.NullString{String: v, Valid: true}
+	}
+	if v := query.Get("action"); v != "" {
+		params.Action = sql.NullString{String: v, Valid: true}
+	}
+	for name, dest := range map[string]*sql.NullTime{"since": &params.Since, "until": &params.Until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondWithError(w, r, http.StatusBadRequest, "Invalid "+name+": expects an RFC 3339 timestamp", err)
+				return
+			}
+			*dest = sql.NullTime{Time: t, Valid: true}
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 1000 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid limit: accepts 1 to 1000", err)
+			return
+		}
+		params.RowLimit = int32(limit)
+	}
+
+	events, err := cfg.db.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading audit events from DB", err)
+		return
+	}
+
+	returnedEvents := []AuditEvent{}
+	for _, e := range events {
+		event := AuditEvent{
+			ID:         e.ID,
+			CreatedAt:  e.CreatedAt,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			IP:         e.Ip,
+			UserAgent:  e.UserAgent,
+			Diff:       e.Diff,
+		}
+		if e.ActorID.Valid {
+			event.ActorID = &e.ActorID.UUID
+		}
+		returnedEvents = append(returnedEvents, event)
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedEvents)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+	"github.com/mattnickolaus/chirpy/internal/service"
+)
+
+var (
+	errInsufficientScope = errors.New("token is missing the required scope")
+	errSessionRequired   = errors.New("personal access tokens are not accepted here")
+	errInsufficientRole  = errors.New("user role does not allow this action")
+)
+
+// principalCheck is one authorization rule a route applies to its caller.
+type principalCheck func(auth.Principal) error
+
+func notSuspended(p auth.Principal) error {
+	if p.IsSuspended(time.Now()) {
+		return service.SuspendedError{Until: p.SuspendedUntil}
+	}
+	return nil
+}
+
+func hasScope(required auth.Scope) principalCheck {
+	return func(p auth.Principal) error {
+		if !p.HasScope(required) {
+			return errInsufficientScope
+		}
+		return nil
+	}
+}
+
+// sessionOnly only accepts access tokens issued at login, so a leaked personal
+// access token cannot mint new credentials or reach admin endpoints.
+func sessionOnly(p auth.Principal) error {
+	if p.TokenType != auth.TokenTypeJWT {
+		return errSessionRequired
+	}
+	return nil
+}
+
+func hasRole(required auth.Role) principalCheck {
+	return func(p auth.Principal) error {
+		if !p.Role.AtLeast(required) {
+			return errInsufficientRole
+		}
+		return nil
+	}
+}
+
+// authorize authenticates the caller, applies every check in order and hands
+// the request on with the principal in its context.
+func (cfg *apiConfig) authorize(next http.HandlerFunc, checks ...principalCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := cfg.authenticate(r)
+		for _, check := range checks {
+			if err != nil {
+				break
+			}
+			err = check(principal)
+		}
+		if err != nil {
+			respondWithAuthError(w, r, err)
+			return
+		}
+
+		ctx := logging.WithUserID(r.Context(), principal.UserID.String())
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(ctx, principal)))
+	})
+}
+
+// requireAuth rejects anonymous and suspended callers and, for personal
+// access tokens, tokens that were not granted the route's scope.
+func (cfg *apiConfig) requireAuth(required auth.Scope, next http.HandlerFunc) http.Handler {
+	return cfg.authorize(next, notSuspended, hasScope(required))
+}
+
+// requireSession is used by endpoints that manage credentials.
+func (cfg *apiConfig) requireSession(next http.HandlerFunc) http.Handler {
+	return cfg.authorize(next, notSuspended, sessionOnly)
+}
+
+// requireRole only lets through logged-in users whose role grants at least
+// the required one. Personal access tokens never carry admin privileges.
+func (cfg *apiConfig) requireRole(required auth.Role, next http.HandlerFunc) http.Handler {
+	return cfg.authorize(next, notSuspended, sessionOnly, hasRole(required))
+}
+
+// optionalAuth lets anonymous requests through but still rejects a request
+// that sends credentials which turn out to be invalid or under-scoped.
+// Suspended users may keep reading.
+func (cfg *apiConfig) optionalAuth(required auth.Scope, next http.HandlerFunc) http.Handler {
+	authorized := cfg.authorize(next, hasScope(required))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authorized.ServeHTTP(w, r)
+	})
+}
+
+// requestPrincipal returns the caller set by the authentication middleware.
+// Handlers behind optionalAuth must check the second value.
+// principalOrNil returns the caller of an optionally authenticated route, or
+// nil for anonymous callers.
+func principalOrNil(r *http.Request) *auth.Principal {
+	principal, ok := requestPrincipal(r)
+	if !ok {
+		return nil
+	}
+	return &principal
+}
+
+func requestPrincipal(r *http.Request) (auth.Principal, bool) {
+	return auth.PrincipalFromContext(r.Context())
+}
+
+// authenticate accepts either a JWT access token, which carries every scope,
+// or a personal access token limited to the scopes it was granted.
+func (cfg *apiConfig) authenticate(r *http.Request) (auth.Principal, error) {
+	tokenString, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	principal := auth.Principal{}
+	if auth.IsPersonalAccessToken(tokenString) {
+		principal, err = cfg.authenticatePersonalAccessToken(r.Context(), tokenString)
+		if err != nil {
+			return auth.Principal{}, err
+		}
+	} else {
+		userID, err := auth.ValidateJWT(tokenString, cfg.jwtKeys)
+		if err != nil {
+			return auth.Principal{}, err
+		}
+		principal = auth.Principal{
+			UserID:    userID,
+			TokenType: auth.TokenTypeJWT,
+			Scopes:    auth.AllScopes,
+		}
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), principal.UserID)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	principal.Tier = auth.TierFree
+	if user.IsChirpyRed.Bool {
+		principal.Tier = auth.TierChirpyRed
+	}
+	principal.Role, err = auth.ParseRole(user.Role)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if user.SuspendedUntil.Valid {
+		principal.SuspendedUntil = user.SuspendedUntil.Time
+	}
+
+	return principal, nil
+}
+
+func (cfg *apiConfig) authenticatePersonalAccessToken(ctx context.Context, tokenString string) (auth.Principal, error) {
+	pat, err := cfg.lookupPersonalAccessToken(ctx, tokenString)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if pat.RevokedAt.Valid {
+		return auth.Principal{}, fmt.Errorf("personal access token revoked")
+	}
+	if pat.ExpiresAt.Valid && pat.ExpiresAt.Time.Before(time.Now()) {
+		return auth.Principal{}, fmt.Errorf("personal access token expired")
+	}
+
+	scopes, err := auth.ParseScopes(pat.Scopes)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	err = cfg.db.TouchPersonalAccessToken(ctx, pat.ID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Error recording personal access token use", "error", err)
+	}
+
+	return auth.Principal{
+		UserID:    pat.UserID,
+		TokenType: auth.TokenTypePersonalAccessToken,
+		Scopes:    scopes,
+	}, nil
+}
+
+func (cfg *apiConfig) lookupPersonalAccessToken(ctx context.Context, tokenString string) (database.PersonalAccessToken, error) {
+	candidates, err := cfg.db.GetPersonalAccessTokensByPrefix(ctx, auth.PersonalAccessTokenLookupPrefix(tokenString))
+	if err != nil {
+		return database.PersonalAccessToken{}, err
+	}
+
+	for _, c := range candidates {
+		if auth.CheckTokenHash(tokenString, c.TokenHash) {
+			return c, nil
+		}
+	}
+
+	return database.PersonalAccessToken{}, sql.ErrNoRows
+}
+
+func respondWithAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errInsufficientScope):
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: Token missing required scope", err)
+	case errors.Is(err, errSessionRequired):
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: Personal access tokens are not accepted here", err)
+	case errors.As(err, &service.SuspendedError{}):
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: "+err.Error(), err)
+	case errors.Is(err, errInsufficientRole):
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: Insufficient role", err)
+	default:
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Token Invalid", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/service"
+)
+
+const bootstrapAdminUsage = `usage: chirpy bootstrap-admin [flags] <user-id>
+
+Promotes an existing account to admin while no admin exists. Later roles are
+assigned through PUT /admin/users/{userID}/role.
+`
+
+// runBootstrapAdmin implements "chirpy bootstrap-admin". Promotion is only
+// ever done here by whoever operates the server, so signing up with a
+// particular email can't grant admin. Only the database settings are needed,
+// so the rest of the configuration is not validated.
+func runBootstrapAdmin(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("chirpy bootstrap-admin", flag.ExitOnError)
+	conf, err := config.Parse(fs, args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(bootstrapAdminUsage)
+	}
+	userID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", fs.Arg(0), err)
+	}
+	if conf.Database.Driver == "memory" {
+		return errors.New("the in-memory store keeps no users to promote")
+	}
+	if conf.Database.URL == "" {
+		return errors.New("database.url (DB_URL) is required")
+	}
+
+	store, db, err := openStore(ctx, conf.Database, slog.Default())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	previousRole, err := service.New(store, service.Config{}).BootstrapAdmin(ctx, userID)
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return fmt.Errorf("no user with ID %s", userID)
+	case errors.Is(err, service.ErrAdminExists):
+		return errors.New("an admin already exists; assign roles through PUT /admin/users/{userID}/role")
+	case err != nil:
+		return err
+	}
+
+	diff, err := json.Marshal(auditDiff{"role": {From: previousRole, To: string(auth.RoleAdmin)}})
+	if err != nil {
+		return err
+	}
+	err = store.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		Action:     auditUserRoleChanged,
+		TargetType: auditTargetUser,
+		TargetID:   userID.String(),
+		UserAgent:  "chirpy bootstrap-admin",
+		Diff:       diff,
+	})
+	if err != nil {
+		return fmt.Errorf("promoted %s but failed to record the audit event: %w", userID, err)
+	}
+
+	fmt.Fprintf(out, "Promoted %s to admin\n", userID)
+	return nil
+}
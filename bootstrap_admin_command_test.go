@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+)
+
+func TestRunBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "chirpy.db")
+	db, err := storage.OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := storage.NewSQLiteStore(db)
+	first, err := store.CreateUser(ctx, database.CreateUserParams{Email: "first@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.CreateUser(ctx, database.CreateUserParams{Email: "second@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	tests := []struct {
+		name    string
+		driver  string
+		dbURL   string
+		args    []string
+		wantErr string
+		wantOut string
+	}{
+		{
+			name:    "Test 1: No User ID",
+			driver:  "sqlite",
+			dbURL:   path,
+			args:    []string{},
+			wantErr: "usage: chirpy bootstrap-admin",
+		},
+		{
+			name:    "Test 2: Invalid User ID",
+			driver:  "sqlite",
+			dbURL:   path,
+			args:    []string{"admin@example.com"},
+			wantErr: `invalid user ID "admin@example.com"`,
+		},
+		{
+			name:    "Test 3: Memory Driver",
+			driver:  "memory",
+			args:    []string{first.ID.String()},
+			wantErr: "in-memory store",
+		},
+		{
+			name:    "Test 4: Missing Database URL",
+			driver:  "sqlite",
+			args:    []string{first.ID.String()},
+			wantErr: "DB_URL",
+		},
+		{
+			name:    "Test 5: Unknown User",
+			driver:  "sqlite",
+			dbURL:   path,
+			args:    []string{"00000000-0000-0000-0000-000000000000"},
+			wantErr: "no user with ID",
+		},
+		{
+			name:    "Test 6: First Admin",
+			driver:  "sqlite",
+			dbURL:   path,
+			args:    []string{first.ID.String()},
+			wantOut: "Promoted " + first.ID.String() + " to admin\n",
+		},
+		{
+			name:    "Test 7: Admin Already Exists",
+			driver:  "sqlite",
+			dbURL:   path,
+			args:    []string{second.ID.String()},
+			wantErr: "an admin already exists",
+		},
+	}
+
+	// Cases run in order against one database, so later cases see earlier writes
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_DRIVER", tt.driver)
+			t.Setenv("DB_URL", tt.dbURL)
+			t.Setenv("CHIRPY_CONFIG", "")
+			out := &bytes.Buffer{}
+			err := runBootstrapAdmin(ctx, tt.args, out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v; want error containing: %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("got: %q; want: %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+
+	db, err = storage.OpenSQLite(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store = storage.NewSQLiteStore(db)
+	for _, want := range []struct {
+		user database.User
+		role auth.Role
+	}{{first, auth.RoleAdmin}, {second, auth.RoleUser}} {
+		got, err := store.GetUserByID(ctx, want.user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Role != string(want.role) {
+			t.Errorf("%s role got: %v; want: %v", want.user.Email, got.Role, want.role)
+		}
+	}
+
+	events, err := store.ListAuditEvents(ctx, database.ListAuditEventsParams{
+		TargetID: sql.NullString{String: first.ID.String(), Valid: true},
+		RowLimit: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Action != auditUserRoleChanged || events[0].ActorID.Valid {
+		t.Errorf("got: %+v; want one %s event with no actor", events, auditUserRoleChanged)
+	}
+}
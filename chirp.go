@@ -2,52 +2,36 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sort"
-	"strings"
 
-	"github.com/mattnickolaus/chirpy/internal/auth"
 	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/service"
+	"github.com/mattnickolaus/chirpy/internal/spam"
 
 	"github.com/google/uuid"
 )
 
-var PROFANITY = map[string]struct{}{
-	"kerfuffle": {},
-	"sharbert":  {},
-	"fornax":    {},
-}
-
-func filterProfanity(chirpText string) string {
-
-	chirpWords := strings.Split(chirpText, " ")
-
-	for i, w := range chirpWords {
-		lowercaseWord := strings.ToLower(w)
-		if _, containsProf := PROFANITY[lowercaseWord]; containsProf {
-			chirpWords[i] = "****"
-		}
-	}
-
-	return strings.Join(chirpWords, " ")
-}
-
 func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 	chirpIDPath := r.PathValue("chirpID")
 	if chirpIDPath == "" {
-		respondWithError(w, http.StatusBadRequest, "Unable to retrieve chirpID from path", nil)
+		respondWithError(w, r, http.StatusBadRequest, "Unable to retrieve chirpID from path", nil)
 		return
 	}
 	chirpID, err := uuid.Parse(chirpIDPath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to parse the uuid from provide path", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to parse the uuid from provide path", err)
 		return
 	}
 
-	readChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
-
+	readChirp, err := cfg.service.GetChirp(r.Context(), principalOrNil(r), chirpID)
+	if errors.Is(err, service.ErrNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Chirp was not read from database", err)
+		return
+	}
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Chirp was not read from database", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading Chirp from DB", err)
 		return
 	}
 
@@ -59,35 +43,36 @@ func (cfg *apiConfig) getChirp(w http.ResponseWriter, r *http.Request) {
 		UserID:    readChirp.UserID,
 	}
 
+	setChirpCacheControl(w, r)
+	etag := chirpETag(returnedChirp.ID, returnedChirp.UpdatedAt)
+	if checkNotModified(w, r, etag, returnedChirp.UpdatedAt) {
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, returnedChirp)
 }
 
 func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
 	authorID := r.URL.Query().Get("author_id")
 
-	returnedChirps := []database.Chirp{}
+	authorUserID := uuid.NullUUID{}
 	if authorID != "" {
-		authorUserID, err := uuid.Parse(authorID)
-		if err != nil {
-			respondWithError(w, http.StatusNotFound, "No chirps by that author_id were found", err)
-			return
-		}
-		returnedChirps, err = cfg.db.GetAllChirpsByUser(r.Context(), authorUserID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Error: No chirps by that author_id were found", err)
-			return
-		}
-	} else {
-		allChirps, err := cfg.db.GetAllChirps(r.Context())
+		parsed, err := uuid.Parse(authorID)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Error Reading Chirps from DB", err)
+			respondWithError(w, r, http.StatusNotFound, "No chirps by that author_id were found", err)
 			return
 		}
-		returnedChirps = allChirps
+		authorUserID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	returnedChirps, err := cfg.service.ListChirps(r.Context(), principalOrNil(r), authorUserID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading Chirps from DB", err)
+		return
 	}
 
 	responseChirps := []Chirp{}
-	for _, c := range returnedChirps {
+	for _, c := range returnedChirps.Chirps {
 		newChirp := Chirp{
 			ID:        c.ID,
 			CreatedAt: c.CreatedAt.Time,
@@ -100,7 +85,7 @@ func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
 
 	sortType := r.URL.Query().Get("sort")
 	if sortType != "desc" && sortType != "asc" && sortType != "" {
-		respondWithError(w, http.StatusNotFound, "Invalid sort parameter: accepts only 'asc' or 'desc'", nil)
+		respondWithError(w, r, http.StatusNotFound, "Invalid sort parameter: accepts only 'asc' or 'desc'", nil)
 		return
 	}
 	if sortType == "desc" {
@@ -109,52 +94,55 @@ func (cfg *apiConfig) getAllChirps(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	setChirpCacheControl(w, r)
+	if checkNotModified(w, r, chirpListETag(responseChirps), returnedChirps.ChangedAt) {
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, responseChirps)
 }
 
 func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 	type chripRead struct {
-		Body string `json:"body"`
+		Body   string `json:"body"`
+		Locale string `json:"locale"`
 	}
 
-	tokenString, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(tokenString, cfg.secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid:", err)
-		return
-	}
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
 
 	decoder := json.NewDecoder(r.Body)
 	c := chripRead{}
 
-	err = decoder.Decode(&c)
+	err := decoder.Decode(&c)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
 
-	chirpMaxLength := 140
-	if len(c.Body) > chirpMaxLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
-		return
+	writenChirp, err := cfg.service.CreateChirp(r.Context(), userID, c.Body, c.Locale)
+	if errors.Is(err, service.ErrRejectedAsSpam) {
+		cfg.metrics.SpamDecisions.WithLabelValues(string(spam.VerdictReject)).Inc()
 	}
-
-	cleanedChirp := filterProfanity(c.Body)
-
-	chirpParam := database.CreateChirpParams{
-		Body:   cleanedChirp,
-		UserID: userID,
+	validationErr := service.ValidationError{}
+	if errors.As(err, &validationErr) {
+		respondWithError(w, r, http.StatusBadRequest, validationErr.Error(), nil)
+		return
 	}
-	writenChirp, err := cfg.db.CreateChirp(r.Context(), chirpParam)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Chirp failed to write to database", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Chirp failed to write to database", err)
 		return
 	}
 
+	cfg.metrics.SpamDecisions.WithLabelValues(string(writenChirp.Spam.Verdict)).Inc()
+	cfg.metrics.ChirpsCreated.WithLabelValues(string(writenChirp.Spam.Verdict)).Inc()
+
+	// Held chirps are posted hidden and queued for review like reported ones
+	status := http.StatusCreated
+	if writenChirp.Held() {
+		status = http.StatusAccepted
+	}
+
 	returnedChirp := Chirp{
 		ID:        writenChirp.ID,
 		CreatedAt: writenChirp.CreatedAt.Time,
@@ -163,47 +151,56 @@ func (cfg *apiConfig) createChirp(w http.ResponseWriter, r *http.Request) {
 		UserID:    writenChirp.UserID,
 	}
 
-	respondWithJSON(w, http.StatusCreated, returnedChirp)
+	respondWithJSON(w, status, returnedChirp)
 }
 
 func (cfg *apiConfig) deleteChirp(w http.ResponseWriter, r *http.Request) {
-	tokenString, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(tokenString, cfg.secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid:", err)
-		return
-	}
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
 
 	chirpIDPath := r.PathValue("chirpID")
 	if chirpIDPath == "" {
-		respondWithError(w, http.StatusBadRequest, "Unable to retrieve chirpID from path", nil)
+		respondWithError(w, r, http.StatusBadRequest, "Unable to retrieve chirpID from path", nil)
 		return
 	}
 	chirpID, err := uuid.Parse(chirpIDPath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to parse the uuid from provide path", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to parse the uuid from provide path", err)
 		return
 	}
 
-	queriedChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
-	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Chirp of that ID was not found in Database", err)
+	// If-Match lets a client delete only the version it last read
+	expect := func(c database.Chirp) bool {
+		return !ifMatchFails(r, chirpETag(c.ID, c.UpdatedAt.Time))
+	}
+	queriedChirp, err := cfg.service.DeleteChirp(r.Context(), principal, chirpID, expect)
+	if errors.Is(err, service.ErrNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Chirp of that ID was not found in Database", err)
 		return
 	}
-	if queriedChirp.UserID != userID {
-		respondWithError(w, http.StatusForbidden, "Not authorized to delete Chirp", nil)
+	if errors.Is(err, service.ErrForbidden) {
+		respondWithError(w, r, http.StatusForbidden, "Not authorized to delete Chirp", nil)
+		return
+	}
+	if errors.Is(err, service.ErrChanged) {
+		respondWithError(w, r, http.StatusPreconditionFailed, "Chirp has changed since it was read", nil)
 		return
 	}
-
-	err = cfg.db.DeleteChirpByID(r.Context(), chirpID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Chirp failed to detele to database", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Chirp failed to detele to database", err)
 		return
 	}
 
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &userID,
+		Action:     auditChirpDeleted,
+		TargetType: auditTargetChirp,
+		TargetID:   chirpID.String(),
+		Diff: auditDiff{
+			"author": {From: queriedChirp.UserID},
+			"body":   {From: queriedChirp.Body},
+		},
+	})
+
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"testing"
+
+	"github.com/mattnickolaus/chirpy/internal/profanity"
 )
 
 func TestFilterProfanity(t *testing.T) {
@@ -28,13 +30,30 @@ func TestFilterProfanity(t *testing.T) {
 		{
 			name:  "Test 4: Profanity with puntuation",
 			input: "I really need a kerfuffle to go to bed sooner, Fornax!",
-			want:  "I really need a **** to go to bed sooner, Fornax!",
+			want:  "I really need a **** to go to bed sooner, ****!",
+		},
+		{
+			name:  "Test 5: Profanity in leetspeak",
+			input: "What a k3rfuffl3, $harbert",
+			want:  "What a ****, ****",
+		},
+		{
+			name:  "Test 6: Profanity with diacritics",
+			input: "Førnax? Fôrnax... fornáx",
+			want:  "****? ****... ****",
+		},
+		{
+			name:  "Test 7: Profanity inside a longer word",
+			input: "Fornaxes are not kerfuffles",
+			want:  "Fornaxes are not kerfuffles",
 		},
 	}
 
+	filter := profanity.NewFilter(profanity.DefaultWords)
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := filterProfanity(tt.input)
+			actual := filter.Check(profanity.DefaultLocale, tt.input).Text
 			expected := tt.want
 
 			if actual != expected {
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("parseTrustedProxies errored: %v", err)
+	}
+	cfg := apiConfig{trustedProxies: proxies}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{
+			name:       "Test 1: Direct Connection",
+			remoteAddr: "203.0.113.7:5000",
+			want:       "203.0.113.7",
+		},
+		{
+			name:         "Test 2: Untrusted Peer Sending Header",
+			remoteAddr:   "203.0.113.7:5000",
+			forwardedFor: "198.51.100.1",
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "Test 3: Trusted Proxy",
+			remoteAddr:   "10.1.2.3:5000",
+			forwardedFor: "198.51.100.1",
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "Test 4: Spoofed Entry Left Of Real Client",
+			remoteAddr:   "10.1.2.3:5000",
+			forwardedFor: "1.1.1.1, 198.51.100.1, 192.168.1.1",
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "Test 5: Only Trusted Hops",
+			remoteAddr:   "10.1.2.3:5000",
+			forwardedFor: "10.9.9.9",
+			want:         "10.9.9.9",
+		},
+		{
+			name:         "Test 6: Malformed Entry",
+			remoteAddr:   "10.1.2.3:5000",
+			forwardedFor: "not-an-ip",
+			want:         "10.1.2.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			actual := cfg.clientIP(r)
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/compress"
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+)
+
+// chirpETag is a strong validator for one chirp. Edits bump updated_at, so
+// the pair changes whenever the body does.
+func chirpETag(id uuid.UUID, updatedAt time.Time) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s:%d", id, updatedAt.UnixNano()))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// chirpListETag returns a weak ETag for a list response. It covers which
+// chirps are listed and in what order, so it also changes when a chirp is
+// deleted, hidden or its author suspended. Their Last-Modified is the store's
+// chirps changed time, which those move too.
+func chirpListETag(chirps []Chirp) string {
+	h := sha256.New()
+	for _, c := range chirps {
+		fmt.Fprintf(h, "%s:%d\n", c.ID, c.UpdatedAt.UnixNano())
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// setChirpCacheControl makes clients and shared caches revalidate chirp
+// responses before reusing them. Logged-in viewers may see chirps others
+// can't, such as their own hidden ones, so only their client may store those.
+func setChirpCacheControl(w http.ResponseWriter, r *http.Request) {
+	if principalOrNil(r) == nil {
+		w.Header().Set("Cache-Control", "public, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	httpx.AddVary(w.Header(), "Authorization")
+}
+
+// checkNotModified sets the validators on w and answers 304 when the client's
+// copy is still current, reporting whether it did. If-None-Match wins over
+// If-Modified-Since, which only has one second resolution.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Values("If-None-Match"); len(ifNoneMatch) > 0 {
+		matched, ok := matchETagList(ifNoneMatch, etag, false)
+		if !ok {
+			return false
+		}
+		// The client may hold a compressed representation, whose ETag the
+		// 304 must repeat
+		w.Header().Set("ETag", matched)
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatchFails reports whether r's If-Match header rules out the resource
+// whose current ETag is etag. Without the header any version may be written.
+func ifMatchFails(r *http.Request, etag string) bool {
+	ifMatch := r.Header.Values("If-Match")
+	if len(ifMatch) == 0 {
+		return false
+	}
+	_, ok := matchETagList(ifMatch, etag, true)
+	return !ok
+}
+
+// etagForms are etag and the ETags the compress middleware gives its
+// compressed representations, any of which a client may send back.
+func etagForms(etag string) []string {
+	if strings.HasPrefix(etag, "W/") {
+		return []string{etag}
+	}
+	return []string{etag, compress.EncodedETag(etag, compress.Brotli), compress.EncodedETag(etag, compress.Gzip)}
+}
+
+// matchETagList compares etag with the comma separated entity tags in headers
+// and returns the form of etag that matched. Strong comparison, used by
+// If-Match, never matches weak tags.
+func matchETagList(headers []string, etag string, strong bool) (string, bool) {
+	for _, header := range headers {
+		for _, candidate := range strings.Split(header, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" {
+				return etag, true
+			}
+			for _, form := range etagForms(etag) {
+				if strong {
+					if candidate == form && !strings.HasPrefix(form, "W/") {
+						return form, true
+					}
+				} else if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(form, "W/") {
+					return form, true
+				}
+			}
+		}
+	}
+	return "", false
+}
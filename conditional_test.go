@@ -0,0 +1,231 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchETagList(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		etag    string
+		strong  bool
+		want    bool
+	}{
+		{
+			name:    "Test 1: Exact Match",
+			headers: []string{`"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "Test 2: One Of Several",
+			headers: []string{`"xyz", "abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "Test 3: Weak Comparison Ignores W/",
+			headers: []string{`W/"abc"`},
+			etag:    `"abc"`,
+			want:    true,
+		},
+		{
+			name:    "Test 4: Strong Comparison Rejects Weak Tags",
+			headers: []string{`W/"abc"`},
+			etag:    `W/"abc"`,
+			strong:  true,
+			want:    false,
+		},
+		{
+			name:    "Test 5: Wildcard",
+			headers: []string{"*"},
+			etag:    `"abc"`,
+			strong:  true,
+			want:    true,
+		},
+		{
+			name:    "Test 6: No Match",
+			headers: []string{`"xyz"`, `"123"`},
+			etag:    `"abc"`,
+			want:    false,
+		},
+		{
+			name:    "Test 7: Compressed Form",
+			headers: []string{`"abc-gzip"`},
+			etag:    `"abc"`,
+			strong:  true,
+			want:    true,
+		},
+		{
+			name:    "Test 8: Other Tag's Compressed Form",
+			headers: []string{`"abc-gzip"`},
+			etag:    `"abcd"`,
+			strong:  true,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := matchETagList(tt.headers, tt.etag, tt.strong)
+			if got != tt.want {
+				t.Errorf("got: %v; want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionalChirpRequests(t *testing.T) {
+	h := newTestAPI(t)
+	alice := signup(t, h, "alice@example.com")
+	chirp := Chirp{}
+	decode(t, serveJSON(t, h, "POST", "/api/chirps", bearer(alice.Token), map[string]string{"body": "Poll me"}), http.StatusCreated, &chirp)
+	chirpPath := "/api/chirps/" + chirp.ID.String()
+
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	single := serve("GET", chirpPath, nil)
+	list := serve("GET", "/api/chirps", nil)
+	for _, rec := range []*httptest.ResponseRecorder{single, list} {
+		if rec.Header().Get("ETag") == "" || rec.Header().Get("Last-Modified") == "" {
+			t.Fatalf("missing validators in %v", rec.Header())
+		}
+	}
+	singleETag := single.Header().Get("ETag")
+	listETag := list.Header().Get("ETag")
+	later := chirp.UpdatedAt.Add(time.Hour).UTC().Format(http.TimeFormat)
+	earlier := chirp.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+
+	// Cases run in order, so the deletes come last
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		headers          map[string]string
+		wantCode         int
+		wantCacheControl string
+	}{
+		{
+			name:             "Test 1: Strong Chirp ETag",
+			method:           "GET",
+			path:             chirpPath,
+			headers:          map[string]string{"If-None-Match": singleETag},
+			wantCode:         http.StatusNotModified,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 2: Stale Chirp ETag",
+			method:           "GET",
+			path:             chirpPath,
+			headers:          map[string]string{"If-None-Match": `"stale"`},
+			wantCode:         http.StatusOK,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 3: Weak List ETag",
+			method:           "GET",
+			path:             "/api/chirps",
+			headers:          map[string]string{"If-None-Match": listETag},
+			wantCode:         http.StatusNotModified,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 4: List Not Modified Since",
+			method:           "GET",
+			path:             "/api/chirps",
+			headers:          map[string]string{"If-Modified-Since": later},
+			wantCode:         http.StatusNotModified,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 5: List Modified Since",
+			method:           "GET",
+			path:             "/api/chirps",
+			headers:          map[string]string{"If-Modified-Since": earlier},
+			wantCode:         http.StatusOK,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 6: Chirp Not Modified Since",
+			method:           "GET",
+			path:             chirpPath,
+			headers:          map[string]string{"If-Modified-Since": later},
+			wantCode:         http.StatusNotModified,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 7: Chirp Modified Since",
+			method:           "GET",
+			path:             chirpPath,
+			headers:          map[string]string{"If-Modified-Since": earlier},
+			wantCode:         http.StatusOK,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 8: If-None-Match Wins",
+			method:           "GET",
+			path:             "/api/chirps",
+			headers:          map[string]string{"If-None-Match": `W/"stale"`, "If-Modified-Since": later},
+			wantCode:         http.StatusOK,
+			wantCacheControl: "public, no-cache",
+		},
+		{
+			name:             "Test 9: Authenticated Viewer",
+			method:           "GET",
+			path:             chirpPath,
+			headers:          map[string]string{"Authorization": bearer(alice.Token), "If-None-Match": singleETag},
+			wantCode:         http.StatusNotModified,
+			wantCacheControl: "private, no-cache",
+		},
+		{
+			name:     "Test 10: Delete Stale Version",
+			method:   "DELETE",
+			path:     chirpPath,
+			headers:  map[string]string{"Authorization": bearer(alice.Token), "If-Match": `"stale"`},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "Test 11: Delete Read Version",
+			method:   "DELETE",
+			path:     chirpPath,
+			headers:  map[string]string{"Authorization": bearer(alice.Token), "If-Match": singleETag},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:             "Test 12: List ETag Changes On Delete",
+			method:           "GET",
+			path:             "/api/chirps",
+			headers:          map[string]string{"If-None-Match": listETag},
+			wantCode:         http.StatusOK,
+			wantCacheControl: "public, no-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.path, tt.headers)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("got: %v; want: %v (body: %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+				t.Errorf("Cache-Control got: %v; want: %v", got, tt.wantCacheControl)
+			}
+			if rec.Code == http.StatusNotModified && rec.Body.Len() > 0 {
+				t.Errorf("304 has a body: %s", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/cors"
+	"github.com/mattnickolaus/chirpy/internal/secure"
+)
+
+// headerPolicies are the security headers of every response and the
+// overrides for the files under /app/.
+type headerPolicies struct {
+	api secure.Policy
+	// app only sets what differs from api
+	app secure.Policy
+}
+
+func newHeaderPolicies(c config.Security) headerPolicies {
+	policies := headerPolicies{
+		api: secure.Policy{
+			// JSON is never rendered, so nothing in it may load or run
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors " + c.FrameAncestors,
+			ContentTypeOptions:    "nosniff",
+			ReferrerPolicy:        c.ReferrerPolicy,
+		},
+		app: secure.Policy{
+			ContentSecurityPolicy: c.AppCSP + "; frame-ancestors " + c.FrameAncestors,
+		},
+	}
+	switch c.FrameAncestors {
+	case "'none'":
+		policies.api.FrameOptions = "DENY"
+	case "'self'":
+		policies.api.FrameOptions = "SAMEORIGIN"
+	}
+	if c.HSTSMaxAge > 0 {
+		policies.api.StrictTransportSecurity = "max-age=" + strconv.Itoa(int(c.HSTSMaxAge.Seconds()))
+		if c.HSTSIncludeSubdomains {
+			policies.api.StrictTransportSecurity += "; includeSubDomains"
+		}
+	}
+	return policies
+}
+
+func newCORSOptions(c config.CORS) cors.Options {
+	return cors.Options{
+		AllowedOrigins:   c.AllowedOrigins,
+		AllowedMethods:   c.AllowedMethods,
+		AllowedHeaders:   c.AllowedHeaders,
+		ExposedHeaders:   c.ExposedHeaders,
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           c.MaxAge,
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/config"
+)
+
+func TestBrowserHeaders(t *testing.T) {
+	h := newTestAPI(t)
+	defaults := config.Default().Security
+	apiCSP := "default-src 'none'; frame-ancestors 'none'"
+	appCSP := defaults.AppCSP + "; frame-ancestors 'none'"
+	hsts := "max-age=31536000"
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		headers     map[string]string
+		wantCode    int
+		wantHeaders map[string]string
+	}{
+		{
+			name:     "Test 1: API Route",
+			method:   "GET",
+			path:     "/api/chirps",
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Content-Security-Policy":     apiCSP,
+				"Strict-Transport-Security":   hsts,
+				"X-Content-Type-Options":      "nosniff",
+				"X-Frame-Options":             "DENY",
+				"Referrer-Policy":             "strict-origin-when-cross-origin",
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:     "Test 2: API Error",
+			method:   "GET",
+			path:     "/api/chirps/00000000-0000-0000-0000-000000000000",
+			wantCode: http.StatusNotFound,
+			wantHeaders: map[string]string{
+				"Content-Security-Policy": apiCSP,
+				"X-Content-Type-Options":  "nosniff",
+			},
+		},
+		{
+			name:     "Test 3: Static Route",
+			method:   "GET",
+			path:     "/app/",
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Content-Security-Policy":   appCSP,
+				"Strict-Transport-Security": hsts,
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+			},
+		},
+		{
+			name:     "Test 4: Missing Static File",
+			method:   "GET",
+			path:     "/app/missing.js",
+			wantCode: http.StatusNotFound,
+			wantHeaders: map[string]string{
+				"Content-Security-Policy": appCSP,
+				"X-Content-Type-Options":  "nosniff",
+			},
+		},
+		{
+			name:     "Test 5: Cross-Origin API Request",
+			method:   "GET",
+			path:     "/api/chirps",
+			headers:  map[string]string{"Origin": testOrigin},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   testOrigin,
+				"Access-Control-Expose-Headers": strings.Join(config.Default().CORS.ExposedHeaders, ", "),
+				"Content-Security-Policy":       apiCSP,
+			},
+		},
+		{
+			name:   "Test 6: Preflight",
+			method: "OPTIONS",
+			path:   "/api/chirps/00000000-0000-0000-0000-000000000000",
+			headers: map[string]string{
+				"Origin":                         testOrigin,
+				"Access-Control-Request-Method":  "DELETE",
+				"Access-Control-Request-Headers": "Authorization, If-Match",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  testOrigin,
+				"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE",
+				"Access-Control-Max-Age":       "600",
+				"Content-Security-Policy":      apiCSP,
+				"X-Content-Type-Options":       "nosniff",
+			},
+		},
+		{
+			name:   "Test 7: Preflight From Other Origin",
+			method: "OPTIONS",
+			path:   "/api/chirps",
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+				"Content-Security-Policy":      apiCSP,
+			},
+		},
+		{
+			name:     "Test 8: Cross-Origin Static Request",
+			method:   "GET",
+			path:     "/app/",
+			headers:  map[string]string{"Origin": testOrigin},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": testOrigin,
+				"Content-Security-Policy":     appCSP,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("got: %v; want: %v (body: %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s got: %q; want: %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHeaderPolicies(t *testing.T) {
+	tests := []struct {
+		name             string
+		change           func(*config.Security)
+		wantHSTS         string
+		wantFrameOptions string
+		wantAppCSP       string
+	}{
+		{
+			name:             "Test 1: Defaults",
+			change:           func(*config.Security) {},
+			wantHSTS:         "max-age=31536000",
+			wantFrameOptions: "DENY",
+			wantAppCSP:       config.Default().Security.AppCSP + "; frame-ancestors 'none'",
+		},
+		{
+			name: "Test 2: HSTS Off",
+			change: func(s *config.Security) {
+				s.HSTSMaxAge = 0
+				s.HSTSIncludeSubdomains = true
+			},
+			wantFrameOptions: "DENY",
+			wantAppCSP:       config.Default().Security.AppCSP + "; frame-ancestors 'none'",
+		},
+		{
+			name: "Test 3: HSTS With Subdomains",
+			change: func(s *config.Security) {
+				s.HSTSMaxAge = 24 * time.Hour
+				s.HSTSIncludeSubdomains = true
+			},
+			wantHSTS:         "max-age=86400; includeSubDomains",
+			wantFrameOptions: "DENY",
+			wantAppCSP:       config.Default().Security.AppCSP + "; frame-ancestors 'none'",
+		},
+		{
+			name: "Test 4: Framed By Own Origin",
+			change: func(s *config.Security) {
+				s.FrameAncestors = "'self'"
+			},
+			wantHSTS:         "max-age=31536000",
+			wantFrameOptions: "SAMEORIGIN",
+			wantAppCSP:       config.Default().Security.AppCSP + "; frame-ancestors 'self'",
+		},
+		{
+			name: "Test 5: Framed By Partner",
+			change: func(s *config.Security) {
+				s.AppCSP = "default-src 'self'"
+				s.FrameAncestors = "https://partner.example.com"
+			},
+			wantHSTS:   "max-age=31536000",
+			wantAppCSP: "default-src 'self'; frame-ancestors https://partner.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			security := config.Default().Security
+			tt.change(&security)
+			got := newHeaderPolicies(security)
+
+			if got.api.StrictTransportSecurity != tt.wantHSTS {
+				t.Errorf("HSTS got: %q; want: %q", got.api.StrictTransportSecurity, tt.wantHSTS)
+			}
+			if got.api.FrameOptions != tt.wantFrameOptions {
+				t.Errorf("X-Frame-Options got: %q; want: %q", got.api.FrameOptions, tt.wantFrameOptions)
+			}
+			if got.app.ContentSecurityPolicy != tt.wantAppCSP {
+				t.Errorf("app CSP got: %q; want: %q", got.app.ContentSecurityPolicy, tt.wantAppCSP)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"net/http"
@@ -32,12 +34,13 @@ func CheckPasswordHash(password, hash string) (bool, error) {
 	return match, nil
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
 	issueAt := time.Now().UTC()
 	expiresAt := issueAt.Add(expiresIn)
 
+	signingKey := keys.signingKey()
 	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
+		signingKey.Method,
 		jwt.RegisteredClaims{
 			Issuer:    "chirpy",
 			IssuedAt:  jwt.NewNumericDate(issueAt),
@@ -45,18 +48,16 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 			Subject:   userID.String(),
 		},
 	)
-	return token.SignedString([]byte(tokenSecret))
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+	return token.SignedString(signingKey.private)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
 	claims := jwt.RegisteredClaims{}
 
-	t, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v\n", token.Header["alg"])
-		}
-		return []byte(tokenSecret), nil
-	})
+	t, err := jwt.ParseWithClaims(tokenString, &claims, keys.verificationKey)
 	if err != nil {
 		return uuid.Nil, err
 	}
@@ -76,21 +77,36 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	return userID, nil
 }
 
-func GetBearerToken(headers http.Header) (string, error) {
+// getAuthorizationCredentials splits the Authorization header into scheme and
+// credentials and only accepts the expected scheme, compared case-insensitively.
+func getAuthorizationCredentials(headers http.Header, scheme string) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
 		return "No Auth Header Found", fmt.Errorf("Authorization Header is blank\n")
 	}
 
-	tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-	if tokenString == "" {
-		return "No Token String Found", fmt.Errorf("No token_string found\n")
+	headerScheme, credentials, found := strings.Cut(authHeader, " ")
+	if !found || !strings.EqualFold(headerScheme, scheme) {
+		return "Wrong Auth Scheme", fmt.Errorf("Authorization Header does not use the %s scheme\n", scheme)
+	}
+
+	credentials = strings.TrimSpace(credentials)
+	if credentials == "" {
+		return "No Credentials Found", fmt.Errorf("No %s credentials found\n", scheme)
 	}
 
-	return tokenString, nil
+	return credentials, nil
+}
+
+func GetBearerToken(headers http.Header) (string, error) {
+	return getAuthorizationCredentials(headers, "Bearer")
 }
 
-func MakeRefreshToken() (string, error) {
+// Refresh tokens and personal access tokens are never stored raw. Only a
+// short lookup prefix and the SHA-256 hash of the full token are persisted.
+const tokenLookupPrefixLength = 8
+
+func makeRandomToken() (string, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
 	if err != nil {
@@ -100,15 +116,44 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func GetApiKey(headers http.Header) (string, error) {
-	authHeader := headers.Get("Authorization")
-	if authHeader == "" {
-		return "No Auth Header Found", fmt.Errorf("Authorization Header is blank\n")
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func lookupPrefix(s string) string {
+	if len(s) < tokenLookupPrefixLength {
+		return s
 	}
-	apiKeyString := strings.Replace(authHeader, "ApiKey ", "", 1)
-	if apiKeyString == "" {
-		return "No API Key Found", fmt.Errorf("No API Key found\n")
+	return s[:tokenLookupPrefixLength]
+}
+
+// MakeRefreshToken returns a new random refresh token along with the prefix
+// and hash that should be written to the database in its place.
+func MakeRefreshToken() (token, prefix, hash string, err error) {
+	token, err = makeRandomToken()
+	if err != nil {
+		return "", "", "", err
 	}
 
-	return apiKeyString, nil
+	prefix, hash = HashRefreshToken(token)
+	return token, prefix, hash, nil
+}
+
+func HashRefreshToken(token string) (prefix, hash string) {
+	return RefreshTokenPrefix(token), hashToken(token)
+}
+
+func RefreshTokenPrefix(token string) string {
+	return lookupPrefix(token)
+}
+
+// CheckTokenHash compares a refresh or personal access token against a stored
+// hash in constant time.
+func CheckTokenHash(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(hash)) == 1
+}
+
+func GetApiKey(headers http.Header) (string, error) {
+	return getAuthorizationCredentials(headers, "ApiKey")
 }
@@ -85,14 +85,14 @@ func TestValidateJWT(t *testing.T) {
 				t.Errorf("Parsing userID string errored: %v\n", err)
 			}
 
-			tokenString, err := MakeJWT(userID, tt.tokenSecretOnCreate, tt.expiresIn)
+			tokenString, err := MakeJWT(userID, NewHMACKeySet(tt.tokenSecretOnCreate), tt.expiresIn)
 			if err != nil {
 				t.Errorf("MakeJWT errored: %v\n", err)
 			}
 			fmt.Printf("Token String: %v\n", tokenString)
 
 			// Purposely ignoring error to test the outupt match
-			actualUserID, _ := ValidateJWT(tokenString, tt.tokenSecretOnValidate)
+			actualUserID, _ := ValidateJWT(tokenString, NewHMACKeySet(tt.tokenSecretOnValidate))
 
 			actualMatch := actualUserID == userID
 			if actualMatch != tt.match {
@@ -150,6 +150,56 @@ func TestGetBearerToken(t *testing.T) {
 	}
 }
 
+func TestGetBearerTokenScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		authHeader string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "Test 1: Lowercase Scheme Accepted",
+			authHeader: "bearer abc.def.ghi",
+			want:       "abc.def.ghi",
+		},
+		{
+			name:       "Test 2: Missing Scheme Rejected",
+			authHeader: "abc.def.ghi",
+			wantErr:    true,
+		},
+		{
+			name:       "Test 3: Other Scheme Rejected",
+			authHeader: "ApiKey abc.def.ghi",
+			wantErr:    true,
+		},
+		{
+			name:       "Test 4: Scheme Prefix Without Separator Rejected",
+			authHeader: "Bearerabc.def.ghi",
+			wantErr:    true,
+		},
+		{
+			name:       "Test 5: Scheme Only Rejected",
+			authHeader: "Bearer ",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			header.Add("Authorization", tt.authHeader)
+
+			actual, err := GetBearerToken(header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err: %v; want err: %v", err, tt.wantErr)
+			}
+			if err == nil && actual != tt.want {
+				t.Errorf("got: %q; want: %q", actual, tt.want)
+			}
+		})
+	}
+}
+
 func TestMakeRefreshToken(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -163,7 +213,7 @@ func TestMakeRefreshToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			refreshToken, err := MakeRefreshToken()
+			refreshToken, _, _, err := MakeRefreshToken()
 			if err != nil {
 				t.Errorf("Error Making Refresh Token: %v", err)
 			}
@@ -177,3 +227,52 @@ func TestMakeRefreshToken(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckTokenHash(t *testing.T) {
+	refreshToken, prefix, hash, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Error Making Refresh Token: %v", err)
+	}
+	otherToken, _, _, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Error Making Refresh Token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		match bool
+	}{
+		{
+			name:  "Test 1: Same Token Matches Stored Hash",
+			token: refreshToken,
+			match: true,
+		},
+		{
+			name:  "Test 2: Different Token Does Not Match",
+			token: otherToken,
+			match: false,
+		},
+		{
+			name:  "Test 3: Stored Hash Is Not Accepted As Token",
+			token: hash,
+			match: false,
+		},
+	}
+
+	if prefix != refreshToken[:tokenLookupPrefixLength] {
+		t.Errorf("got prefix: %v; want: %v", prefix, refreshToken[:tokenLookupPrefixLength])
+	}
+	if hash == refreshToken {
+		t.Errorf("hash should not equal the raw token")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualMatch := CheckTokenHash(tt.token, hash)
+			if actualMatch != tt.match {
+				t.Errorf("got: %v; want: %v", actualMatch, tt.match)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyManifestFile is the name of the manifest LoadKeySet expects to find in
+// the key directory.
+const KeyManifestFile = "keys.json"
+
+// SigningKey is a single entry of a KeySet. Verification-only keys have no
+// private half and are kept around so tokens signed before a rotation stay
+// valid until VerifyUntil.
+type SigningKey struct {
+	ID          string
+	Method      jwt.SigningMethod
+	private     any
+	public      any
+	VerifyUntil time.Time
+}
+
+func (k *SigningKey) canSign() bool {
+	return k.private != nil
+}
+
+func (k *SigningKey) canVerify(now time.Time) bool {
+	return k.VerifyUntil.IsZero() || now.Before(k.VerifyUntil)
+}
+
+// KeySet holds every key tokens may be verified with and the one key new
+// tokens are signed with.
+type KeySet struct {
+	signingKID string
+	keys       map[string]*SigningKey
+}
+
+// NewHMACKeySet returns a key set that signs and verifies with a single shared
+// HS256 secret. Its key is never published through the JWKS endpoint.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &SigningKey{
+		ID:      "",
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &KeySet{
+		signingKID: key.ID,
+		keys:       map[string]*SigningKey{key.ID: key},
+	}
+}
+
+type keyManifest struct {
+	SigningKID string `json:"signing_kid"`
+	Keys       []struct {
+		KID         string    `json:"kid"`
+		File        string    `json:"file"`
+		VerifyUntil time.Time `json:"verify_until"`
+	} `json:"keys"`
+}
+
+// LoadKeySet reads a keys.json manifest and the PEM files it references from
+// dir. Private keys may be RSA (RS256) or Ed25519 (EdDSA); public-only PEM
+// files are accepted for keys that are being retired.
+func LoadKeySet(dir string) (*KeySet, error) {
+	data, err := os.ReadFile(filepath.Join(dir, KeyManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := keyManifest{}
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", KeyManifestFile, err)
+	}
+
+	ks := &KeySet{
+		signingKID: manifest.SigningKID,
+		keys:       map[string]*SigningKey{},
+	}
+	for _, entry := range manifest.Keys {
+		if entry.KID == "" {
+			return nil, fmt.Errorf("key %q has no kid", entry.File)
+		}
+		if _, exists := ks.keys[entry.KID]; exists {
+			return nil, fmt.Errorf("duplicate kid %q", entry.KID)
+		}
+
+		pemBytes, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseSigningKey(entry.KID, pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		key.VerifyUntil = entry.VerifyUntil
+		ks.keys[entry.KID] = key
+	}
+
+	signingKey, ok := ks.keys[ks.signingKID]
+	if !ok {
+		return nil, fmt.Errorf("signing kid %q not found in key set", ks.signingKID)
+	}
+	if !signingKey.canSign() {
+		return nil, fmt.Errorf("signing kid %q has no private key", ks.signingKID)
+	}
+	if !signingKey.canVerify(time.Now()) {
+		return nil, fmt.Errorf("signing kid %q stopped verifying at %s", ks.signingKID, signingKey.VerifyUntil.Format(time.RFC3339))
+	}
+
+	return ks, nil
+}
+
+func parseSigningKey(kid string, pemBytes []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block found", kid)
+	}
+
+	var private, public any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key %q: unsupported private key type %T", kid, parsed)
+		}
+		private, public = parsed, signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		private, public = parsed, &parsed.PublicKey
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kid, err)
+		}
+		public = parsed
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", kid, block.Type)
+	}
+
+	key := &SigningKey{
+		ID:      kid,
+		private: private,
+		public:  public,
+	}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %T", kid, public)
+	}
+
+	return key, nil
+}
+
+func (ks *KeySet) signingKey() *SigningKey {
+	return ks.keys[ks.signingKID]
+}
+
+// verificationKey picks the key for a parsed token by its kid header. Tokens
+// without a kid were issued before key IDs existed and fall back to the
+// current signing key.
+func (ks *KeySet) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = ks.signingKID
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %q", kid)
+	}
+	if !key.canVerify(time.Now()) {
+		return nil, fmt.Errorf("kid %q has been retired", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+// JWK is the public JSON Web Key representation of a signing key.
+type JWK struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	Curve   string `json:"crv,omitempty"`
+	X       string `json:"x,omitempty"`
+	N       string `json:"n,omitempty"`
+	E       string `json:"e,omitempty"`
+}
+
+// JWKS returns the public halves of every key that can still verify tokens.
+// Symmetric keys are never included.
+func (ks *KeySet) JWKS() []JWK {
+	now := time.Now()
+	jwks := []JWK{}
+	for _, key := range ks.keys {
+		if !key.canVerify(now) {
+			continue
+		}
+
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				KeyType: "RSA",
+				KeyID:   key.ID,
+				Use:     "sig",
+				Alg:     key.Method.Alg(),
+				N:       base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				KeyType: "OKP",
+				KeyID:   key.ID,
+				Use:     "sig",
+				Alg:     key.Method.Alg(),
+				Curve:   "Ed25519",
+				X:       base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(jwks, func(i, j int) bool {
+		return jwks[i].KeyID < jwks[j].KeyID
+	})
+	return jwks
+}
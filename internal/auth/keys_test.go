@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, dir, file, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, file), data, 0o600); err != nil {
+		t.Fatalf("writing %s: %v", file, err)
+	}
+}
+
+func writeManifest(t *testing.T, dir string, manifest any) {
+	t.Helper()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("marshalling manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, KeyManifestFile), data, 0o600); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+}
+
+// newTestKeyDir writes an Ed25519 signing key, a still valid RSA key and an
+// already retired Ed25519 key to a temp dir.
+func newTestKeyDir(t *testing.T) (string, *rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("marshalling ed25519 key: %v", err)
+	}
+	writePEM(t, dir, "current.pem", "PRIVATE KEY", edDER)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating rsa key: %v", err)
+	}
+	writePEM(t, dir, "previous.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	retiredPub, retiredKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating ed25519 key: %v", err)
+	}
+	retiredDER, err := x509.MarshalPKIXPublicKey(retiredPub)
+	if err != nil {
+		t.Fatalf("marshalling ed25519 public key: %v", err)
+	}
+	writePEM(t, dir, "retired.pem", "PUBLIC KEY", retiredDER)
+
+	writeManifest(t, dir, map[string]any{
+		"signing_kid": "current",
+		"keys": []map[string]any{
+			{"kid": "current", "file": "current.pem"},
+			{"kid": "previous", "file": "previous.pem", "verify_until": time.Now().Add(time.Hour)},
+			{"kid": "retired", "file": "retired.pem", "verify_until": time.Now().Add(-time.Hour)},
+		},
+	})
+
+	return dir, rsaKey, retiredKey
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key any, userID uuid.UUID) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return signed
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir, rsaKey, retiredKey := newTestKeyDir(t)
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatalf("LoadKeySet errored: %v", err)
+	}
+	userID := uuid.New()
+
+	signed, err := MakeJWT(userID, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT errored: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("parsing token header: %v", err)
+	}
+	if parsed.Header["kid"] != "current" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("got kid %v alg %v; want kid current alg EdDSA", parsed.Header["kid"], parsed.Method.Alg())
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		match bool
+	}{
+		{
+			name:  "Test 1: Token From Active Key",
+			token: signed,
+			match: true,
+		},
+		{
+			name:  "Test 2: Token From Previous Key Within Overlap",
+			token: signWith(t, jwt.SigningMethodRS256, "previous", rsaKey, userID),
+			match: true,
+		},
+		{
+			name:  "Test 3: Token From Retired Key",
+			token: signWith(t, jwt.SigningMethodEdDSA, "retired", retiredKey, userID),
+			match: false,
+		},
+		{
+			name:  "Test 4: Token With Unknown kid",
+			token: signWith(t, jwt.SigningMethodRS256, "unknown", rsaKey, userID),
+			match: false,
+		},
+		{
+			name:  "Test 5: Token Claiming Wrong kid For Its Algorithm",
+			token: signWith(t, jwt.SigningMethodRS256, "current", rsaKey, userID),
+			match: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Purposely ignoring error to test the outupt match
+			actualUserID, _ := ValidateJWT(tt.token, keys)
+
+			actualMatch := actualUserID == userID
+			if actualMatch != tt.match {
+				t.Errorf("got: %v; want: %v", actualMatch, tt.match)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	dir, _, _ := newTestKeyDir(t)
+	keys, err := LoadKeySet(dir)
+	if err != nil {
+		t.Fatalf("LoadKeySet errored: %v", err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("got %d keys; want 2 (retired key must be excluded)", len(jwks))
+	}
+	if jwks[0].KeyID != "current" || jwks[0].KeyType != "OKP" || jwks[0].X == "" {
+		t.Errorf("unexpected Ed25519 JWK: %+v", jwks[0])
+	}
+	if jwks[1].KeyID != "previous" || jwks[1].KeyType != "RSA" || jwks[1].N == "" || jwks[1].E != "AQAB" {
+		t.Errorf("unexpected RSA JWK: %+v", jwks[1])
+	}
+
+	if hmacKeys := NewHMACKeySet("secret").JWKS(); len(hmacKeys) != 0 {
+		t.Errorf("HMAC secret must never be published, got %+v", hmacKeys)
+	}
+}
+
+func TestLoadKeySetRejectsPublicSigningKey(t *testing.T) {
+	dir, _, _ := newTestKeyDir(t)
+	writeManifest(t, dir, map[string]any{
+		"signing_kid": "retired",
+		"keys": []map[string]any{
+			{"kid": "retired", "file": "retired.pem"},
+		},
+	})
+
+	if _, err := LoadKeySet(dir); err == nil {
+		t.Errorf("expected error when signing key has no private half")
+	}
+}
+
+func TestLoadKeySetRejectsExpiredSigningKey(t *testing.T) {
+	dir, _, _ := newTestKeyDir(t)
+	writeManifest(t, dir, map[string]any{
+		"signing_kid": "current",
+		"keys": []map[string]any{
+			{"kid": "current", "file": "current.pem", "verify_until": time.Now().Add(-time.Hour)},
+		},
+	})
+
+	if _, err := LoadKeySet(dir); err == nil {
+		t.Errorf("expected error when signing key's verify_until has passed")
+	}
+}
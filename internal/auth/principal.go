@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenType string
+
+const (
+	TokenTypeJWT                 TokenType = "jwt"
+	TokenTypePersonalAccessToken TokenType = "personal_access_token"
+)
+
+type Tier string
+
+const (
+	TierFree      Tier = "free"
+	TierChirpyRed Tier = "chirpy_red"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    uuid.UUID
+	TokenType TokenType
+	Scopes    []Scope
+	Tier      Tier
+	Role      Role
+	// SuspendedUntil is zero for users who have never been suspended.
+	SuspendedUntil time.Time
+}
+
+func (p Principal) HasScope(required Scope) bool {
+	return slices.Contains(p.Scopes, required)
+}
+
+func (p Principal) IsSuspended(now time.Time) bool {
+	return p.SuspendedUntil.After(now)
+}
+
+type principalContextKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the caller stored by the authentication
+// middleware. The second value is false for anonymous requests.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(Principal)
+	return p, ok
+}
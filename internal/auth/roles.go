@@ -0,0 +1,37 @@
+package auth
+
+import "fmt"
+
+// Role is a user's privilege level. Each role includes everything the roles
+// below it may do.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role: %q", s)
+	}
+	return role, nil
+}
+
+// AtLeast reports whether r grants the privileges of required. Unknown roles
+// grant nothing.
+func (r Role) AtLeast(required Role) bool {
+	rank, ok := roleRank[r]
+	if !ok {
+		return false
+	}
+	return rank >= roleRank[required]
+}
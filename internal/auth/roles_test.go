@@ -0,0 +1,36 @@
+package auth
+
+import "testing"
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     Role
+		required Role
+		want     bool
+	}{
+		{name: "Test 1: User Is Not Moderator", role: RoleUser, required: RoleModerator, want: false},
+		{name: "Test 2: Moderator Is Moderator", role: RoleModerator, required: RoleModerator, want: true},
+		{name: "Test 3: Admin Is Moderator", role: RoleAdmin, required: RoleModerator, want: true},
+		{name: "Test 4: Moderator Is Not Admin", role: RoleModerator, required: RoleAdmin, want: false},
+		{name: "Test 5: Unknown Role Grants Nothing", role: Role("root"), required: RoleUser, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.role.AtLeast(tt.required)
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	if role, err := ParseRole("moderator"); err != nil || role != RoleModerator {
+		t.Errorf("got: %v, %v; want: moderator", role, err)
+	}
+	if _, err := ParseRole("superuser"); err == nil {
+		t.Errorf("expected error for unknown role")
+	}
+}
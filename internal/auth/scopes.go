@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scope limits what a personal access token is allowed to do. Access tokens
+// issued at login carry every scope.
+type Scope string
+
+const (
+	ScopeChirpsRead  Scope = "chirps:read"
+	ScopeChirpsWrite Scope = "chirps:write"
+)
+
+var AllScopes = []Scope{
+	ScopeChirpsRead,
+	ScopeChirpsWrite,
+}
+
+// retiredScopes are still accepted, and dropped, so tokens created before
+// their routes stopped taking personal access tokens keep working.
+var retiredScopes = []Scope{"profile:write"}
+
+// PersonalAccessTokenPrefix marks a bearer token as a personal access token
+// rather than a JWT.
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+func ParseScopes(scopes []string) ([]Scope, error) {
+	parsed := []Scope{}
+	for _, s := range scopes {
+		scope := Scope(s)
+		if slices.Contains(retiredScopes, scope) {
+			continue
+		}
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("unknown scope: %q", s)
+		}
+		if !slices.Contains(parsed, scope) {
+			parsed = append(parsed, scope)
+		}
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	return parsed, nil
+}
+
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// MakePersonalAccessToken returns a new token along with the prefix and hash
+// that should be written to the database in its place.
+func MakePersonalAccessToken() (token, prefix, hash string, err error) {
+	secret, err := makeRandomToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	token = PersonalAccessTokenPrefix + secret
+	prefix, hash = HashPersonalAccessToken(token)
+	return token, prefix, hash, nil
+}
+
+func HashPersonalAccessToken(token string) (prefix, hash string) {
+	return PersonalAccessTokenLookupPrefix(token), hashToken(token)
+}
+
+// PersonalAccessTokenLookupPrefix skips the fixed marker so the stored prefix
+// actually narrows the lookup.
+func PersonalAccessTokenLookupPrefix(token string) string {
+	return lookupPrefix(strings.TrimPrefix(token, PersonalAccessTokenPrefix))
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []string
+		want    []Scope
+		wantErr bool
+	}{
+		{
+			name:  "Test 1: Known Scopes",
+			input: []string{"chirps:write", "chirps:read"},
+			want:  []Scope{ScopeChirpsWrite, ScopeChirpsRead},
+		},
+		{
+			name:  "Test 2: Duplicate Scopes Collapse",
+			input: []string{"chirps:read", "chirps:read"},
+			want:  []Scope{ScopeChirpsRead},
+		},
+		{
+			name:    "Test 3: Unknown Scope",
+			input:   []string{"chirps:write", "admin"},
+			wantErr: true,
+		},
+		{
+			name:    "Test 4: No Scopes",
+			input:   []string{},
+			wantErr: true,
+		},
+		{
+			name:  "Test 5: Retired Scope Dropped",
+			input: []string{"chirps:write", "profile:write"},
+			want:  []Scope{ScopeChirpsWrite},
+		},
+		{
+			name:    "Test 6: Only Retired Scopes",
+			input:   []string{"profile:write"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseScopes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got err: %v; want err: %v", err, tt.wantErr)
+			}
+			if !slices.Equal(actual, tt.want) {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakePersonalAccessToken(t *testing.T) {
+	token, prefix, hash, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatalf("Error Making Personal Access Token: %v", err)
+	}
+
+	if !IsPersonalAccessToken(token) {
+		t.Errorf("token %q is missing the %q marker", token, PersonalAccessTokenPrefix)
+	}
+	if strings.HasPrefix(PersonalAccessTokenPrefix, prefix) || len(prefix) != tokenLookupPrefixLength {
+		t.Errorf("lookup prefix %q should come from the random part of the token", prefix)
+	}
+	if !CheckTokenHash(token, hash) {
+		t.Errorf("token does not match its own hash")
+	}
+	if IsPersonalAccessToken("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.sig") {
+		t.Errorf("JWT should not be treated as a personal access token")
+	}
+}
@@ -0,0 +1,26 @@
+// Package cache keeps encoded values under string keys for a limited time,
+// either in process memory or in a server speaking the Redis protocol so
+// replicas share one cache.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores byte values by key. A missing or expired key is reported by
+// Get as found false with a nil error. Implementations are safe for
+// concurrent use, and callers must not modify the slices they pass in or get
+// back.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Clear removes every key stored through this cache.
+	Clear(ctx context.Context) error
+}
+
+var (
+	_ Cache = (*LRU)(nil)
+	_ Cache = (*Redis)(nil)
+)
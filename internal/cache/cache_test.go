@@ -0,0 +1,294 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a stand-in server speaking enough of RESP2 for Redis: AUTH,
+// SELECT, GET, SET with PX, DEL and SCAN. Expiry follows now.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string][]byte
+	expires  map[string]time.Time
+	password string
+	now      func() time.Time
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string, now func() time.Time) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{
+		values:   map[string][]byte{},
+		expires:  map[string]time.Time{},
+		password: password,
+		now:      now,
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		request, err := readReply(r)
+		if err != nil {
+			return
+		}
+		args := []string{}
+		for _, arg := range request.([]any) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		reply := "-NOAUTH Authentication required.\r\n"
+		if authed || args[0] == "AUTH" {
+			reply = f.handle(args, &authed)
+		}
+		_, err = conn.Write([]byte(reply))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) handle(args []string, authed *bool) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args[0])
+
+	for key, at := range f.expires {
+		if !f.now().Before(at) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+
+	switch args[0] {
+	case "AUTH":
+		if args[len(args)-1] != f.password {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		*authed = true
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		ms, _ := strconv.Atoi(args[4])
+		f.values[args[1]] = []byte(args[2])
+		f.expires[args[1]] = f.now().Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "SCAN":
+		// Everything comes back in one page, so the cursor is always 0
+		keys := []string{}
+		for key := range f.values {
+			if ok, _ := path.Match(args[3], key); ok {
+				keys = append(keys, fmt.Sprintf("$%d\r\n%s\r\n", len(key), key))
+			}
+		}
+		return fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n%s", len(keys), strings.Join(keys, ""))
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	lru := NewLRU(100, 1<<20)
+	lru.now = clock
+	_, addr := startFakeRedis(t, "hunter2", clock)
+	redis, err := NewRedis("redis://:hunter2@"+addr+"/1", "chirpy:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	for name, c := range map[string]Cache{"lru": lru, "redis": redis} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			get := func(key string) string {
+				t.Helper()
+				value, found, err := c.Get(ctx, key)
+				if err != nil {
+					t.Fatalf("Get(%q) errored: %v", key, err)
+				}
+				if !found {
+					return "<missing>"
+				}
+				return string(value)
+			}
+
+			if got := get("chirp:1"); got != "<missing>" {
+				t.Errorf("got: %v; want: <missing>", got)
+			}
+
+			c.Set(ctx, "chirp:1", []byte("one"), time.Minute)
+			c.Set(ctx, "chirp:2", []byte("two"), time.Minute)
+			c.Set(ctx, "user:1", []byte("short"), time.Second)
+			if got := get("chirp:1"); got != "one" {
+				t.Errorf("got: %v; want: one", got)
+			}
+
+			c.Set(ctx, "chirp:1", []byte("uno"), time.Minute)
+			if got := get("chirp:1"); got != "uno" {
+				t.Errorf("overwrite got: %v; want: uno", got)
+			}
+
+			now = now.Add(2 * time.Second)
+			if got := get("user:1"); got != "<missing>" {
+				t.Errorf("expired got: %v; want: <missing>", got)
+			}
+
+			err := c.Delete(ctx, "chirp:1", "chirp:404")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := get("chirp:1"); got != "<missing>" {
+				t.Errorf("deleted got: %v; want: <missing>", got)
+			}
+			if got := get("chirp:2"); got != "two" {
+				t.Errorf("got: %v; want: two", got)
+			}
+
+			err = c.Clear(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := get("chirp:2"); got != "<missing>" {
+				t.Errorf("cleared got: %v; want: <missing>", got)
+			}
+		})
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int64
+		wantKept   []string
+	}{
+		{
+			name:       "Test 1: Entry Bound",
+			maxEntries: 2,
+			maxBytes:   1 << 10,
+			wantKept:   []string{"a", "c"},
+		},
+		{
+			// Each entry is a one byte key and a three byte value
+			name:       "Test 2: Byte Bound",
+			maxEntries: 10,
+			maxBytes:   9,
+			wantKept:   []string{"a", "c"},
+		},
+		{
+			name:       "Test 3: Too Large To Store",
+			maxEntries: 10,
+			maxBytes:   3,
+			wantKept:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewLRU(tt.maxEntries, tt.maxBytes)
+			c.Set(ctx, "a", []byte("one"), time.Minute)
+			c.Set(ctx, "b", []byte("two"), time.Minute)
+			// Reading a makes b the least recently used
+			c.Get(ctx, "a")
+			c.Set(ctx, "c", []byte("six"), time.Minute)
+
+			kept := []string{}
+			for _, key := range []string{"a", "b", "c"} {
+				if _, found, _ := c.Get(ctx, key); found {
+					kept = append(kept, key)
+				}
+			}
+			if fmt.Sprint(kept) != fmt.Sprint(tt.wantKept) {
+				t.Errorf("got: %v; want: %v", kept, tt.wantKept)
+			}
+			if c.Len() != len(tt.wantKept) {
+				t.Errorf("len got: %v; want: %v", c.Len(), len(tt.wantKept))
+			}
+		})
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	ctx := context.Background()
+	fake, addr := startFakeRedis(t, "hunter2", time.Now)
+
+	wrong, err := NewRedis("redis://:wrong@"+addr, "chirpy:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = wrong.Get(ctx, "chirp:1")
+	if !errors.As(err, new(redisError)) {
+		t.Errorf("got: %v; want: a WRONGPASS error", err)
+	}
+
+	_, err = NewRedis("http://"+addr, "chirpy:")
+	if err == nil {
+		t.Errorf("got: nil; want: an error for the http scheme")
+	}
+
+	// The connection is reused after a successful command
+	right, err := NewRedis("redis://:hunter2@"+addr, "chirpy:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer right.Close()
+	for range 3 {
+		_, _, err = right.Get(ctx, "chirp:1")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	auths := strings.Count(strings.Join(fake.commands, " "), "AUTH")
+	if auths != 2 {
+		t.Errorf("AUTH sent got: %v; want: 2, one per client", auths)
+	}
+}
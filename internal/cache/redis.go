@@ -0,0 +1,316 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisDefaultPort = "6379"
+	// redisIdleConns is how many connections are kept open between commands
+	redisIdleConns = 8
+	// redisTimeout bounds each command when the context has no earlier
+	// deadline, so a hung server slows requests down rather than stalling them
+	redisTimeout = 2 * time.Second
+	// redisScanCount is the batch size Clear asks SCAN for
+	redisScanCount = "100"
+)
+
+// Redis is a Cache on a server speaking RESP2, the Redis protocol, such as
+// Redis, Valkey or KeyDB. Keys are stored under a prefix so that Clear only
+// removes this application's keys from a shared server.
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	prefix   string
+	idle     chan *redisConn
+}
+
+// NewRedis connects lazily to the server at rawURL, which is either
+// host:port or redis://[[user]:password@]host[:port][/db].
+func NewRedis(rawURL, prefix string) (*Redis, error) {
+	c := &Redis{
+		addr:   rawURL,
+		prefix: prefix,
+		idle:   make(chan *redisConn, redisIdleConns),
+	}
+	if !strings.Contains(rawURL, "://") {
+		return c, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("cache: invalid redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("cache: redis URL scheme must be redis, got %q", u.Scheme)
+	}
+	c.addr = u.Host
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), redisDefaultPort)
+	}
+	if password, ok := u.User.Password(); ok {
+		c.username = u.User.Username()
+		c.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("cache: invalid redis database %q", db)
+		}
+	}
+	return c, nil
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	return value, ok, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	// PX takes whole milliseconds and rejects zero
+	ms := max(ttl.Milliseconds(), 1)
+	_, err := c.do(ctx, "SET", c.prefix+key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, c.prefix+key)
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Clear walks the prefix with SCAN rather than flushing the database, which
+// may hold other applications' keys. Keys written while it runs may survive.
+func (c *Redis) Clear(ctx context.Context) error {
+	pattern := globEscape(c.prefix) + "*"
+	cursor := "0"
+	for {
+		reply, err := c.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", redisScanCount)
+		if err != nil {
+			return err
+		}
+		page, ok := reply.([]any)
+		if !ok || len(page) != 2 {
+			return errors.New("cache: malformed SCAN reply")
+		}
+		next, _ := page[0].([]byte)
+		found, _ := page[1].([]any)
+
+		args := []string{"DEL"}
+		for _, key := range found {
+			if key, ok := key.([]byte); ok {
+				args = append(args, string(key))
+			}
+		}
+		if len(args) > 1 {
+			_, err = c.do(ctx, args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+// Close closes the idle connections. Commands still running close their own
+// connections when they finish.
+func (c *Redis) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisError is an error reply from the server. Unlike network errors it
+// leaves the connection usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "cache: redis: " + string(e)
+}
+
+// do sends one command and reads its reply: nil, string, int64, []byte or
+// []any.
+func (c *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(ctx, args)
+	if err != nil && !errors.As(err, new(redisError)) {
+		conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	return reply, err
+}
+
+func (c *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("cache: connecting to redis: %w", err)
+	}
+	conn := &redisConn{
+		Conn: netConn,
+		r:    bufio.NewReader(netConn),
+		w:    bufio.NewWriter(netConn),
+	}
+
+	setup := [][]string{}
+	if c.username != "" {
+		setup = append(setup, []string{"AUTH", c.username, c.password})
+	} else if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		_, err = conn.roundTrip(ctx, args)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cache: %s: %w", args[0], err)
+		}
+	}
+	return conn, nil
+}
+
+// release keeps conn for the next command, or closes it when enough
+// connections are already idle.
+func (c *Redis) release(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (conn *redisConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	err := conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeCommand(conn.w, args)
+	if err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+// writeCommand sends args as an array of bulk strings.
+func writeCommand(w *bufio.Writer, args []string) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return w.Flush()
+}
+
+// readReply reads one RESP2 value. Null bulk strings and arrays are nil.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("cache: malformed redis reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]any, n)
+		// An error element is kept so the rest of the array is still read
+		for i := range values {
+			values[i], err = readReply(r)
+			if errors.As(err, new(redisError)) {
+				values[i] = err
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("cache: unknown redis reply type %q", kind)
+}
+
+// globEscape quotes the characters SCAN MATCH treats as wildcards.
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
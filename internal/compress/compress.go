@@ -0,0 +1,288 @@
+// Package compress negotiates gzip and brotli response compression with
+// Accept-Encoding, both for responses produced on the fly and for static
+// files stored precompressed next to the originals.
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+)
+
+// Content codings, in order of preference when a client rates them equally.
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+var encodings = []string{Brotli, Gzip}
+
+// brotliLevel trades some ratio for speed, since responses are compressed
+// while the client waits
+const brotliLevel = 5
+
+var (
+	gzipWriters   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, brotliLevel) }}
+)
+
+// incompressibleTypes are media types whose formats are compressed already,
+// so compressing them again costs CPU for nothing.
+var incompressibleTypes = []string{
+	"application/gzip",
+	"application/x-gzip",
+	"application/zip",
+	"application/x-brotli",
+	"application/zstd",
+	"application/pdf",
+	"application/octet-stream",
+	"font/woff",
+	"font/woff2",
+}
+
+// compressible reports whether a response of contentType may shrink when
+// compressed. Images are compressed formats except for SVG.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"):
+		return false
+	}
+	return !slices.Contains(incompressibleTypes, mediaType)
+}
+
+// Accepted returns the encodings Chirpy supports that accept allows, best
+// first. Encodings are ranked by their q-value and then by preference, and
+// "*" stands for any encoding accept doesn't name.
+func Accepted(accept string) []string {
+	quality := map[string]float64{}
+	wildcard := -1.0
+	for _, entry := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(entry, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "x-gzip" {
+			name = Gzip
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			quality[name] = q
+		}
+	}
+
+	accepted := []string{}
+	for _, enc := range encodings {
+		q, ok := quality[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > 0 {
+			quality[enc] = q
+			accepted = append(accepted, enc)
+		}
+	}
+	slices.SortStableFunc(accepted, func(a, b string) int {
+		switch {
+		case quality[a] > quality[b]:
+			return -1
+		case quality[a] < quality[b]:
+			return 1
+		}
+		return 0
+	})
+	return accepted
+}
+
+// EncodedETag returns the strong ETag of etag's representation in encoding.
+// The compressed bytes differ from the original's, so they need their own
+// strong validator; weakening it instead would fail every If-Match.
+func EncodedETag(etag, encoding string) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// Middleware compresses responses of at least minSize bytes with the best
+// encoding the client accepts. Responses that are partial, already encoded,
+// marked no-transform or of an incompressible type pass through unchanged.
+// Every response that could have been compressed gets Vary: Accept-Encoding.
+func Middleware(minSize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := ""
+		if accepted := Accepted(r.Header.Get("Accept-Encoding")); len(accepted) > 0 {
+			encoding = accepted[0]
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			head:           r.Method == http.MethodHead,
+			encoding:       encoding,
+			minSize:        minSize,
+		}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter holds back the first minSize bytes of the body, so small
+// responses can be sent as they are, before choosing how to send it.
+type compressWriter struct {
+	http.ResponseWriter
+	head     bool
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+	release func()
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	// Informational responses come before the real one
+	if status < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+	if !bodyAllowed(status) {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minSize {
+			return len(p), nil
+		}
+		err := cw.decide(true)
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what is buffered, compressing it if it could be, since a
+// handler that flushes is streaming and will likely send more.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(true)
+	}
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide writes the header, compressed if large is set and the response
+// allows it, and then the buffered body.
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+	h := cw.Header()
+
+	// Sniff like net/http would, so the type can be checked
+	if h.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	candidate := h.Get("Content-Encoding") == "" &&
+		h.Get("Content-Range") == "" &&
+		cw.status != http.StatusPartialContent &&
+		!strings.Contains(h.Get("Cache-Control"), "no-transform") &&
+		(h.Get("Content-Type") == "" || compressible(h.Get("Content-Type")))
+	if candidate {
+		httpx.AddVary(h, "Accept-Encoding")
+	}
+
+	if candidate && large && cw.encoding != "" && bodyAllowed(cw.status) && !cw.head {
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		h.Set("Content-Encoding", cw.encoding)
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", EncodedETag(etag, cw.encoding))
+		}
+		cw.encoder, cw.release = newEncoder(cw.encoding, cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends a body that never reached minSize and finishes the encoder.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(false)
+	}
+	if cw.encoder != nil {
+		cw.encoder.Close()
+		cw.release()
+	}
+}
+
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, func()) {
+	if encoding == Brotli {
+		bw := brotliWriters.Get().(*brotli.Writer)
+		bw.Reset(w)
+		return bw, func() { brotliWriters.Put(bw) }
+	}
+	gw := gzipWriters.Get().(*gzip.Writer)
+	gw.Reset(w)
+	return gw, func() { gzipWriters.Put(gw) }
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,347 @@
+package compress
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestAccepted(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   []string
+	}{
+		{
+			name:   "Test 1: Nothing Accepted",
+			accept: "",
+			want:   []string{},
+		},
+		{
+			name:   "Test 2: Brotli Preferred On A Tie",
+			accept: "gzip, deflate, br",
+			want:   []string{Brotli, Gzip},
+		},
+		{
+			name:   "Test 3: Q-Values Rank",
+			accept: "br;q=0.5, gzip;q=0.8",
+			want:   []string{Gzip, Brotli},
+		},
+		{
+			name:   "Test 4: Refused With Zero",
+			accept: "gzip, br;q=0",
+			want:   []string{Gzip},
+		},
+		{
+			name:   "Test 5: Wildcard",
+			accept: "*;q=0.5, gzip;q=0",
+			want:   []string{Brotli},
+		},
+		{
+			name:   "Test 6: Identity Only",
+			accept: "identity",
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Accepted(tt.accept)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got: %v; want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// decodeBody undoes the Content-Encoding of a recorded response.
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var r io.Reader = rec.Body
+	switch rec.Header().Get("Content-Encoding") {
+	case Gzip:
+		gr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	case Brotli:
+		r = brotli.NewReader(rec.Body)
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	const minSize = 64
+	large := strings.Repeat(`{"body":"chirp"}`, 10)
+
+	tests := []struct {
+		name         string
+		accept       string
+		contentType  string
+		encoding     string
+		cacheControl string
+		status       int
+		body         string
+		wantEncoding string
+		wantVary     bool
+	}{
+		{
+			name:         "Test 1: Gzip",
+			accept:       "gzip",
+			contentType:  "application/json",
+			status:       http.StatusOK,
+			body:         large,
+			wantEncoding: Gzip,
+			wantVary:     true,
+		},
+		{
+			name:         "Test 2: Brotli",
+			accept:       "gzip, br",
+			contentType:  "application/json",
+			status:       http.StatusOK,
+			body:         large,
+			wantEncoding: Brotli,
+			wantVary:     true,
+		},
+		{
+			name:        "Test 3: Below Minimum Size",
+			accept:      "gzip, br",
+			contentType: "application/json",
+			status:      http.StatusOK,
+			body:        `{"body":"chirp"}`,
+			wantVary:    true,
+		},
+		{
+			name:        "Test 4: Client Accepts Nothing",
+			contentType: "application/json",
+			status:      http.StatusOK,
+			body:        large,
+			wantVary:    true,
+		},
+		{
+			name:        "Test 5: Already Compressed Type",
+			accept:      "gzip, br",
+			contentType: "image/png",
+			status:      http.StatusOK,
+			body:        large,
+		},
+		{
+			name:         "Test 6: Already Encoded",
+			accept:       "gzip, br",
+			contentType:  "text/plain",
+			encoding:     Gzip,
+			status:       http.StatusOK,
+			body:         large,
+			wantEncoding: Gzip,
+		},
+		{
+			name:         "Test 7: No Transform",
+			accept:       "gzip, br",
+			contentType:  "application/json",
+			cacheControl: "no-transform",
+			status:       http.StatusOK,
+			body:         large,
+		},
+		{
+			name:         "Test 8: Error Response",
+			accept:       "gzip",
+			contentType:  "application/json",
+			status:       http.StatusBadRequest,
+			body:         large,
+			wantEncoding: Gzip,
+			wantVary:     true,
+		},
+		{
+			name:     "Test 9: Sniffed Type",
+			accept:   "br",
+			status:   http.StatusOK,
+			body:     "<html>" + large + "</html>",
+			wantVary: true,
+			// text/html is sniffed, so it is compressed
+			wantEncoding: Brotli,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Middleware(minSize, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.cacheControl != "" {
+					w.Header().Set("Cache-Control", tt.cacheControl)
+				}
+				w.WriteHeader(tt.status)
+				// Written in pieces to cross the minimum size part way
+				for chunk := range strings.SplitSeq(tt.body, "}") {
+					io.WriteString(w, chunk+"}")
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/api/chirps", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status got: %v; want: %v", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("encoding got: %q; want: %q", got, tt.wantEncoding)
+			}
+			if gotVary := rec.Header().Get("Vary") == "Accept-Encoding"; gotVary != tt.wantVary {
+				t.Errorf("Vary got: %v; want Accept-Encoding: %v", rec.Header().Values("Vary"), tt.wantVary)
+			}
+			if tt.encoding != "" {
+				return
+			}
+			want := ""
+			for chunk := range strings.SplitSeq(tt.body, "}") {
+				want += chunk + "}"
+			}
+			if got := decodeBody(t, rec); got != want {
+				t.Errorf("body got: %q; want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareEncodesETag(t *testing.T) {
+	h := Middleware(0, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "2")
+		io.WriteString(w, "{}")
+	}))
+	req := httptest.NewRequest("GET", "/api/chirps/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("ETag"); got != `"v1-gzip"` {
+		t.Errorf("got: %v; want: %v", got, `"v1-gzip"`)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "" {
+		t.Errorf("Content-Length got: %v; want it removed", got)
+	}
+}
+
+func TestFileServer(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app.js":     strings.Repeat("console.log('chirp');\n", 20),
+		"app.js.br":  "precompressed brotli",
+		"app.js.gz":  "precompressed gzip",
+		"style.css":  strings.Repeat("body { color: blue; }\n", 20),
+		"index.html": strings.Repeat("<p>Welcome to Chirpy</p>\n", 20),
+		"logo.png":   "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 200),
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := Middleware(64, FileServer(http.Dir(root)))
+
+	tests := []struct {
+		name         string
+		path         string
+		accept       string
+		wantEncoding string
+		wantBody     string
+		wantVary     bool
+	}{
+		{
+			name:         "Test 1: Precompressed Brotli",
+			path:         "/app.js",
+			accept:       "gzip, br",
+			wantEncoding: Brotli,
+			wantBody:     "precompressed brotli",
+			wantVary:     true,
+		},
+		{
+			name:         "Test 2: Precompressed Gzip",
+			path:         "/app.js",
+			accept:       "gzip",
+			wantEncoding: Gzip,
+			wantBody:     "precompressed gzip",
+			wantVary:     true,
+		},
+		{
+			name:     "Test 3: Original For Identity",
+			path:     "/app.js",
+			wantBody: files["app.js"],
+			wantVary: true,
+		},
+		{
+			name:         "Test 4: Compressed On The Fly",
+			path:         "/style.css",
+			accept:       "gzip",
+			wantEncoding: Gzip,
+			wantBody:     files["style.css"],
+			wantVary:     true,
+		},
+		{
+			name:         "Test 5: Directory Index",
+			path:         "/",
+			accept:       "br",
+			wantEncoding: Brotli,
+			wantBody:     files["index.html"],
+			wantVary:     true,
+		},
+		{
+			name:     "Test 6: PNG Left Alone",
+			path:     "/logo.png",
+			accept:   "gzip, br",
+			wantBody: files["logo.png"],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got: %v; want: %v", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("encoding got: %q; want: %q", got, tt.wantEncoding)
+			}
+			if gotVary := len(rec.Header().Values("Vary")) == 1 && rec.Header().Get("Vary") == "Accept-Encoding"; gotVary != tt.wantVary {
+				t.Errorf("Vary got: %v; want Accept-Encoding once: %v", rec.Header().Values("Vary"), tt.wantVary)
+			}
+
+			body := rec.Body.String()
+			if !strings.HasPrefix(tt.wantBody, "precompressed") {
+				body = decodeBody(t, rec)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body got: %q; want: %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package compress
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+)
+
+// extensions are the suffixes of precompressed files, e.g. app.js.br.
+var extensions = map[string]string{
+	Brotli: ".br",
+	Gzip:   ".gz",
+}
+
+// FileServer serves root like http.FileServer, except that when a
+// precompressed copy of a file exists, such as app.js.br or app.js.gz, and
+// the client accepts its encoding, the copy is sent instead. Responses for
+// files with a precompressed copy carry Vary: Accept-Encoding either way.
+func FileServer(root http.FileSystem) http.Handler {
+	return &fileServer{root: root, next: http.FileServer(root)}
+}
+
+type fileServer struct {
+	root http.FileSystem
+	next http.Handler
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+
+	// http.FileServer redirects /index.html to the directory, so leave that
+	// to it
+	name := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(name, "/index.html") {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+
+	available := map[string]http.File{}
+	for enc, ext := range extensions {
+		f, err := s.root.Open(name + ext)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err == nil && stat.Mode().IsRegular() {
+			available[enc] = f
+		}
+	}
+	if len(available) == 0 {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+
+	httpx.AddVary(w.Header(), "Accept-Encoding")
+	for _, enc := range Accepted(r.Header.Get("Accept-Encoding")) {
+		f, ok := available[enc]
+		if !ok {
+			continue
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			continue
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Encoding", enc)
+		http.ServeContent(w, r, name, stat.ModTime(), f)
+		return
+	}
+	s.next.ServeHTTP(w, r)
+}
@@ -0,0 +1,269 @@
+// Package config builds Chirpy's settings from, in increasing priority,
+// built-in defaults, a YAML or TOML file, environment variables and
+// command-line flags, and validates them before the server starts.
+package config
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/ratelimit"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// Platform "dev" enables the destructive /admin/reset endpoint
+	Platform   string     `yaml:"platform" toml:"platform"`
+	Server     Server     `yaml:"server" toml:"server"`
+	CORS       CORS       `yaml:"cors" toml:"cors"`
+	Security   Security   `yaml:"security" toml:"security"`
+	Database   Database   `yaml:"database" toml:"database"`
+	Auth       Auth       `yaml:"auth" toml:"auth"`
+	Polka      Polka      `yaml:"polka" toml:"polka"`
+	Chirps     Chirps     `yaml:"chirps" toml:"chirps"`
+	Moderation Moderation `yaml:"moderation" toml:"moderation"`
+	RateLimit  RateLimit  `yaml:"rate_limit" toml:"rate_limit"`
+	Cache      Cache      `yaml:"cache" toml:"cache"`
+	Log        Log        `yaml:"log" toml:"log"`
+	Tracing    Tracing    `yaml:"tracing" toml:"tracing"`
+	Metrics    Metrics    `yaml:"metrics" toml:"metrics"`
+}
+
+type Server struct {
+	Port     int    `yaml:"port" toml:"port"`
+	FileRoot string `yaml:"file_root" toml:"file_root"`
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay keeps serving after a shutdown signal while readiness
+	// fails, so load balancers stop routing here before connections close
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// ShutdownTimeout is how long in-flight requests get to finish once the
+	// server stops accepting connections
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	MaxBodyBytes    int64         `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	// CompressMinBytes is the smallest response body worth compressing
+	CompressMinBytes int `yaml:"compress_min_bytes" toml:"compress_min_bytes"`
+
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For
+	// headers are believed
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type CORS struct {
+	// AllowedOrigins may call the API from a browser, as
+	// scheme://host[:port] or "*" for any. Empty turns CORS off.
+	AllowedOrigins   []string      `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" toml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+type Security struct {
+	// AppCSP is the Content-Security-Policy of the files under /app/. API
+	// responses get default-src 'none', as nothing in them should run.
+	AppCSP string `yaml:"app_csp" toml:"app_csp"`
+	// FrameAncestors are the sources allowed to frame any response, in CSP
+	// syntax; 'none' forbids framing
+	FrameAncestors string `yaml:"frame_ancestors" toml:"frame_ancestors"`
+	ReferrerPolicy string `yaml:"referrer_policy" toml:"referrer_policy"`
+	// HSTSMaxAge is how long browsers must only use HTTPS; 0 sends no
+	// Strict-Transport-Security header
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains" toml:"hsts_include_subdomains"`
+}
+
+type Database struct {
+	// Driver is "postgres", "sqlite" or "memory". For sqlite, URL is the path
+	// of the database file; memory needs no URL and keeps nothing on restart.
+	Driver          string        `yaml:"driver" toml:"driver"`
+	URL             string        `yaml:"url" toml:"url"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	// AutoMigrate applies pending migrations at startup
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type Auth struct {
+	// Secret signs access tokens with HS256 unless JWTKeysDir is set
+	Secret          string        `yaml:"secret" toml:"secret"`
+	JWTKeysDir      string        `yaml:"jwt_keys_dir" toml:"jwt_keys_dir"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	// LoginAttemptStore is "memory" or "postgres"
+	LoginAttemptStore string `yaml:"login_attempt_store" toml:"login_attempt_store"`
+	LockoutNotifyURL  string `yaml:"lockout_notify_url" toml:"lockout_notify_url"`
+}
+
+type Polka struct {
+	APIKey string `yaml:"api_key" toml:"api_key"`
+}
+
+type Chirps struct {
+	MaxLength int `yaml:"max_length" toml:"max_length"`
+}
+
+type Moderation struct {
+	// ReportHideThreshold open reports hide a chirp until it is reviewed
+	ReportHideThreshold     int           `yaml:"report_hide_threshold" toml:"report_hide_threshold"`
+	SpamHoldScore           int           `yaml:"spam_hold_score" toml:"spam_hold_score"`
+	SpamRejectScore         int           `yaml:"spam_reject_score" toml:"spam_reject_score"`
+	ProfanityDir            string        `yaml:"profanity_dir" toml:"profanity_dir"`
+	ProfanityReloadInterval time.Duration `yaml:"profanity_reload_interval" toml:"profanity_reload_interval"`
+}
+
+type RateLimit struct {
+	// Store is "memory" or "postgres"
+	Store           string          `yaml:"store" toml:"store"`
+	Signup          ratelimit.Limit `yaml:"signup" toml:"signup"`
+	Login           ratelimit.Limit `yaml:"login" toml:"login"`
+	Chirps          ratelimit.Limit `yaml:"chirps" toml:"chirps"`
+	ChirpsChirpyRed ratelimit.Limit `yaml:"chirps_chirpy_red" toml:"chirps_chirpy_red"`
+}
+
+type Cache struct {
+	// Backend is "none", "memory" or "redis". Memory caches are per replica,
+	// so writes on one replica leave the others stale for up to TTL; run
+	// several replicas with redis or none.
+	Backend    string        `yaml:"backend" toml:"backend"`
+	TTL        time.Duration `yaml:"ttl" toml:"ttl"`
+	MaxEntries int           `yaml:"max_entries" toml:"max_entries"`
+	MaxBytes   int64         `yaml:"max_bytes" toml:"max_bytes"`
+	// RedisURL is host:port or redis://[[user]:password@]host[:port][/db]
+	RedisURL string `yaml:"redis_url" toml:"redis_url"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter"`
+}
+
+type Metrics struct {
+	Token string `yaml:"token" toml:"token"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Port:              8080,
+			FileRoot:          ".",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+			MaxHeaderBytes:    64 << 10,
+			MaxBodyBytes:      1 << 20,
+			CompressMinBytes:  1 << 10,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", "If-None-Match"},
+			ExposedHeaders: []string{"ETag", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: Security{
+			AppCSP:         "default-src 'self'; img-src 'self' data:; object-src 'none'; base-uri 'self'; form-action 'self'",
+			FrameAncestors: "'none'",
+			ReferrerPolicy: "strict-origin-when-cross-origin",
+			HSTSMaxAge:     365 * 24 * time.Hour,
+		},
+		Database: Database{
+			Driver:          "postgres",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: Auth{
+			AccessTokenTTL:    time.Hour,
+			RefreshTokenTTL:   60 * 24 * time.Hour,
+			LoginAttemptStore: "memory",
+		},
+		Chirps: Chirps{
+			MaxLength: 140,
+		},
+		Moderation: Moderation{
+			ReportHideThreshold:     3,
+			SpamHoldScore:           spam.DefaultConfig.HoldScore,
+			SpamRejectScore:         spam.DefaultConfig.RejectScore,
+			ProfanityReloadInterval: time.Minute,
+		},
+		RateLimit: RateLimit{
+			Store:           "memory",
+			Signup:          ratelimit.Limit{Requests: 5, Per: time.Hour},
+			Login:           ratelimit.Limit{Requests: 20, Per: time.Minute},
+			Chirps:          ratelimit.Limit{Requests: 10, Per: time.Minute},
+			ChirpsChirpyRed: ratelimit.Limit{Requests: 30, Per: time.Minute},
+		},
+		Cache: Cache{
+			Backend:    "none",
+			TTL:        time.Minute,
+			MaxEntries: 10000,
+			MaxBytes:   64 << 20,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+	}
+}
+
+const redacted = "REDACTED"
+
+// Redacted returns a copy with secrets replaced, safe to print or log.
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.Auth.Secret, &c.Polka.APIKey, &c.Metrics.Token} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	// A SQLite URL is a file path and holds no credentials
+	if c.Database.Driver != "sqlite" {
+		c.Database.URL = redactURL(c.Database.URL)
+	}
+	c.Cache.RedisURL = redactURL(c.Cache.RedisURL)
+	return c
+}
+
+// redactURL hides the password of a connection URL. Key=value connection
+// strings can't be picked apart reliably, so they are hidden entirely.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		if raw == "" {
+			return ""
+		}
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	query := u.Query()
+	if query.Has("password") {
+		query.Set("password", redacted)
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// YAML renders the redacted configuration in the format Load reads.
+func (c Config) YAML() ([]byte, error) {
+	return yaml.Marshal(c.Redacted())
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when the -config flag is not given.
+const FileEnv = "CHIRPY_CONFIG"
+
+// binding ties a setting to its flag, named after its key in the config
+// file, and its environment variable.
+type binding struct {
+	key    string
+	env    string
+	usage  string
+	target any
+}
+
+func bindings(c *Config) []binding {
+	return []binding{
+		{"platform", "PLATFORM", `"dev" enables /admin/reset`, &c.Platform},
+
+		{"server.port", "PORT", "port to listen on", &c.Server.Port},
+		{"server.file_root", "FILE_ROOT", "directory served under /app/", &c.Server.FileRoot},
+		{"server.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", "time allowed to read request headers", &c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", "HTTP_READ_TIMEOUT", "time allowed to read a whole request", &c.Server.ReadTimeout},
+		{"server.write_timeout", "HTTP_WRITE_TIMEOUT", "time allowed to write a response", &c.Server.WriteTimeout},
+		{"server.idle_timeout", "HTTP_IDLE_TIMEOUT", "how long idle keep-alive connections stay open", &c.Server.IdleTimeout},
+		{"server.drain_delay", "SHUTDOWN_DRAIN_DELAY", "how long to keep serving with failing readiness after a shutdown signal", &c.Server.DrainDelay},
+		{"server.shutdown_timeout", "SHUTDOWN_TIMEOUT", "how long in-flight requests get to finish on shutdown", &c.Server.ShutdownTimeout},
+		{"server.max_header_bytes", "HTTP_MAX_HEADER_BYTES", "largest accepted request headers", &c.Server.MaxHeaderBytes},
+		{"server.max_body_bytes", "HTTP_MAX_BODY_BYTES", "largest accepted request body", &c.Server.MaxBodyBytes},
+		{"server.compress_min_bytes", "HTTP_COMPRESS_MIN_BYTES", "smallest response body that is compressed", &c.Server.CompressMinBytes},
+		{"server.trusted_proxies", "TRUSTED_PROXIES", "comma separated proxy addresses or CIDRs", &c.Server.TrustedProxies},
+
+		{"cors.allowed_origins", "CORS_ALLOWED_ORIGINS", "comma separated origins allowed to call the API from a browser, or *", &c.CORS.AllowedOrigins},
+		{"cors.allowed_methods", "CORS_ALLOWED_METHODS", "comma separated methods allowed across origins", &c.CORS.AllowedMethods},
+		{"cors.allowed_headers", "CORS_ALLOWED_HEADERS", "comma separated request headers allowed across origins", &c.CORS.AllowedHeaders},
+		{"cors.exposed_headers", "CORS_EXPOSED_HEADERS", "comma separated response headers readable across origins", &c.CORS.ExposedHeaders},
+		{"cors.allow_credentials", "CORS_ALLOW_CREDENTIALS", "let cross-origin requests carry credentials", &c.CORS.AllowCredentials},
+		{"cors.max_age", "CORS_MAX_AGE", "how long browsers may cache preflight answers", &c.CORS.MaxAge},
+
+		{"security.app_csp", "APP_CSP", "Content-Security-Policy of the files under /app/", &c.Security.AppCSP},
+		{"security.frame_ancestors", "FRAME_ANCESTORS", "CSP sources allowed to frame responses", &c.Security.FrameAncestors},
+		{"security.referrer_policy", "REFERRER_POLICY", "Referrer-Policy of every response", &c.Security.ReferrerPolicy},
+		{"security.hsts_max_age", "HSTS_MAX_AGE", "Strict-Transport-Security max-age, 0 to send none", &c.Security.HSTSMaxAge},
+		{"security.hsts_include_subdomains", "HSTS_INCLUDE_SUBDOMAINS", "extend HSTS to subdomains", &c.Security.HSTSIncludeSubdomains},
+
+		{"database.driver", "DB_DRIVER", "postgres, sqlite or memory", &c.Database.Driver},
+		{"database.url", "DB_URL", "Postgres connection string, or SQLite file path", &c.Database.URL},
+		{"database.max_open_conns", "DB_MAX_OPEN_CONNS", "most open database connections", &c.Database.MaxOpenConns},
+		{"database.max_idle_conns", "DB_MAX_IDLE_CONNS", "most idle database connections", &c.Database.MaxIdleConns},
+		{"database.conn_max_lifetime", "DB_CONN_MAX_LIFETIME", "how long a database connection is reused", &c.Database.ConnMaxLifetime},
+		{"database.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME", "how long a database connection may sit idle", &c.Database.ConnMaxIdleTime},
+		{"database.auto_migrate", "DB_AUTO_MIGRATE", "apply pending migrations at startup", &c.Database.AutoMigrate},
+
+		{"auth.secret", "SECRET", "HS256 signing secret, at least 32 bytes", &c.Auth.Secret},
+		{"auth.jwt_keys_dir", "JWT_KEYS_DIR", "directory of asymmetric JWT keys", &c.Auth.JWTKeysDir},
+		{"auth.access_token_ttl", "ACCESS_TOKEN_TTL", "access token lifetime", &c.Auth.AccessTokenTTL},
+		{"auth.refresh_token_ttl", "REFRESH_TOKEN_TTL", "refresh token lifetime", &c.Auth.RefreshTokenTTL},
+		{"auth.login_attempt_store", "LOGIN_ATTEMPT_STORE", "memory or postgres", &c.Auth.LoginAttemptStore},
+		{"auth.lockout_notify_url", "LOCKOUT_NOTIFY_URL", "webhook told about locked accounts", &c.Auth.LockoutNotifyURL},
+
+		{"polka.api_key", "POLKA_KEY", "API key Polka sends with webhooks", &c.Polka.APIKey},
+
+		{"chirps.max_length", "CHIRP_MAX_LENGTH", "longest chirp in bytes", &c.Chirps.MaxLength},
+
+		{"moderation.report_hide_threshold", "REPORT_HIDE_THRESHOLD", "open reports that hide a chirp", &c.Moderation.ReportHideThreshold},
+		{"moderation.spam_hold_score", "SPAM_HOLD_SCORE", "spam score that holds a chirp for review", &c.Moderation.SpamHoldScore},
+		{"moderation.spam_reject_score", "SPAM_REJECT_SCORE", "spam score that rejects a chirp", &c.Moderation.SpamRejectScore},
+		{"moderation.profanity_dir", "PROFANITY_DIR", "directory of <locale>.txt word lists", &c.Moderation.ProfanityDir},
+		{"moderation.profanity_reload_interval", "PROFANITY_RELOAD_INTERVAL", "how often word lists are reloaded", &c.Moderation.ProfanityReloadInterval},
+
+		{"rate_limit.store", "RATE_LIMIT_STORE", "memory or postgres", &c.RateLimit.Store},
+		{"rate_limit.signup", "RATE_LIMIT_SIGNUP", "signups per client IP, as requests/duration", &c.RateLimit.Signup},
+		{"rate_limit.login", "RATE_LIMIT_LOGIN", "login requests per client IP, as requests/duration", &c.RateLimit.Login},
+		{"rate_limit.chirps", "RATE_LIMIT_CHIRPS", "chirps per user, as requests/duration", &c.RateLimit.Chirps},
+		{"rate_limit.chirps_chirpy_red", "RATE_LIMIT_CHIRPS_CHIRPY_RED", "chirps per Chirpy Red user, as requests/duration", &c.RateLimit.ChirpsChirpyRed},
+
+		{"cache.backend", "CACHE_BACKEND", "none, memory or redis", &c.Cache.Backend},
+		{"cache.ttl", "CACHE_TTL", "how long chirps and users stay cached", &c.Cache.TTL},
+		{"cache.max_entries", "CACHE_MAX_ENTRIES", "most entries in the memory cache", &c.Cache.MaxEntries},
+		{"cache.max_bytes", "CACHE_MAX_BYTES", "largest total size of the memory cache", &c.Cache.MaxBytes},
+		{"cache.redis_url", "CACHE_REDIS_URL", "Redis server for the redis cache", &c.Cache.RedisURL},
+
+		{"log.level", "LOG_LEVEL", "debug, info, warn or error", &c.Log.Level},
+		{"log.format", "LOG_FORMAT", "json or text", &c.Log.Format},
+		{"tracing.exporter", "TRACE_EXPORTER", "none, otlp or stdout", &c.Tracing.Exporter},
+		{"metrics.token", "METRICS_TOKEN", "bearer token required by /metrics", &c.Metrics.Token},
+	}
+}
+
+// Load is Parse followed by Validate.
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	c, err := Parse(fs, args, getenv)
+	if err != nil {
+		return Config{}, err
+	}
+	return c, c.Validate()
+}
+
+// Parse registers the settings as flags on fs, parses args and layers
+// defaults, the config file, environment variables read through getenv and
+// the flags. Callers may add their own flags to fs first.
+func Parse(fs *flag.FlagSet, args []string, getenv func(string) string) (Config, error) {
+	c := Default()
+	bound := bindings(&c)
+
+	path := fs.String("config", "", "YAML or TOML config file (env "+FileEnv+")")
+	type flagValue struct {
+		b   binding
+		raw string
+	}
+	flagValues := []flagValue{}
+	for _, b := range bound {
+		usage := b.usage + " (env " + b.env + ")"
+		record := func(raw string) error {
+			flagValues = append(flagValues, flagValue{b: b, raw: raw})
+			return nil
+		}
+		if _, ok := b.target.(*bool); ok {
+			fs.BoolFunc(b.key, usage, record)
+		} else {
+			fs.Func(b.key, usage, record)
+		}
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if *path == "" {
+		*path = getenv(FileEnv)
+	}
+	if *path != "" {
+		err = loadFile(*path, &c)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, b := range bound {
+		if raw := getenv(b.env); raw != "" {
+			err = set(b.target, raw)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %w", b.env, err)
+			}
+		}
+	}
+	for _, v := range flagValues {
+		err = set(v.b.target, v.raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("-%s: %w", v.b.key, err)
+		}
+	}
+
+	return c, nil
+}
+
+// loadFile reads a YAML or TOML file, picked by extension, over c. Unknown
+// keys are errors so typos don't go unnoticed.
+func loadFile(path string, c *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+		// An empty file is a valid, if pointless, config
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), c)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown keys %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, want .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// set parses raw into the setting target points at.
+func set(target any, raw string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = raw
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		*t = v
+	case *int:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		*t = v
+	case *int64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		*t = v
+	case *time.Duration:
+		v, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		*t = v
+	case *[]string:
+		values := []string{}
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		*t = values
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText([]byte(raw))
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mattnickolaus/chirpy/internal/ratelimit"
+	"github.com/mattnickolaus/chirpy/internal/tracing"
+)
+
+// MinSecretLength is the shortest accepted signing secret. HS256 keys
+// shorter than the 32 byte hash output weaken the signature.
+const MinSecretLength = 32
+
+// weakSecrets are placeholders from documentation and examples
+var weakSecrets = []string{
+	"generated-random-string",
+	"changeme",
+	"secret",
+	"password",
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.FileRoot != "", "server.file_root is required")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes must be positive")
+	check(c.Server.MaxBodyBytes > 0, "server.max_body_bytes must be positive")
+	check(c.Server.CompressMinBytes >= 0, "server.compress_min_bytes must not be negative")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins must be scheme://host[:port] or *, got %q", origin)
+	}
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"), "cors.allow_credentials can't be combined with cors.allowed_origins *")
+	check(len(c.CORS.AllowedOrigins) == 0 || len(c.CORS.AllowedMethods) > 0, "cors.allowed_methods must not be empty")
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	// frame-ancestors is added to every policy from security.frame_ancestors
+	check(!strings.Contains(strings.ToLower(c.Security.AppCSP), "frame-ancestors"), "security.app_csp must not set frame-ancestors; use security.frame_ancestors")
+	check(c.Security.FrameAncestors != "", "security.frame_ancestors is required, use 'none' to forbid framing")
+	check(slices.Contains(referrerPolicies, c.Security.ReferrerPolicy), "security.referrer_policy must be a Referrer-Policy value, got %q", c.Security.ReferrerPolicy)
+	check(c.Security.HSTSMaxAge >= 0, "security.hsts_max_age must not be negative")
+
+	check(slices.Contains([]string{"postgres", "sqlite", "memory"}, c.Database.Driver), "database.driver must be postgres, sqlite or memory, got %q", c.Database.Driver)
+	check(c.Database.URL != "" || c.Database.Driver == "memory", "database.url (DB_URL) is required")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must be between 0 and database.max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+
+	// Without asymmetric keys every access token is signed with the secret,
+	// so a guessable one lets anyone forge tokens
+	if c.Auth.JWTKeysDir == "" || c.Auth.Secret != "" {
+		if err := checkSecret(c.Auth.Secret); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl must be longer than auth.access_token_ttl")
+	check(slices.Contains([]string{"memory", "postgres"}, c.Auth.LoginAttemptStore), "auth.login_attempt_store must be memory or postgres, got %q", c.Auth.LoginAttemptStore)
+	check(c.Auth.LoginAttemptStore != "postgres" || c.Database.Driver == "postgres", "auth.login_attempt_store postgres needs database.driver postgres")
+
+	check(c.Polka.APIKey != "", "polka.api_key (POLKA_KEY) is required")
+
+	check(c.Chirps.MaxLength > 0, "chirps.max_length must be positive")
+
+	check(c.Moderation.ReportHideThreshold > 0, "moderation.report_hide_threshold must be positive")
+	check(c.Moderation.SpamHoldScore > 0, "moderation.spam_hold_score must be positive")
+	check(c.Moderation.SpamRejectScore >= c.Moderation.SpamHoldScore, "moderation.spam_reject_score must be at least moderation.spam_hold_score")
+	check(c.Moderation.ProfanityReloadInterval > 0, "moderation.profanity_reload_interval must be positive")
+
+	check(slices.Contains([]string{"memory", "postgres"}, c.RateLimit.Store), "rate_limit.store must be memory or postgres, got %q", c.RateLimit.Store)
+	check(c.RateLimit.Store != "postgres" || c.Database.Driver == "postgres", "rate_limit.store postgres needs database.driver postgres")
+	for name, limit := range map[string]ratelimit.Limit{
+		"signup":            c.RateLimit.Signup,
+		"login":             c.RateLimit.Login,
+		"chirps":            c.RateLimit.Chirps,
+		"chirps_chirpy_red": c.RateLimit.ChirpsChirpyRed,
+	} {
+		check(limit.Requests > 0 && limit.Per > 0, "rate_limit.%s must allow at least one request per positive duration", name)
+	}
+
+	check(slices.Contains([]string{"none", "memory", "redis"}, c.Cache.Backend), "cache.backend must be none, memory or redis, got %q", c.Cache.Backend)
+	check(c.Cache.Backend == "none" || c.Cache.TTL > 0, "cache.ttl must be positive")
+	check(c.Cache.Backend != "memory" || c.Cache.MaxEntries > 0, "cache.max_entries must be positive")
+	check(c.Cache.Backend != "memory" || c.Cache.MaxBytes > 0, "cache.max_bytes must be positive")
+	check(c.Cache.Backend != "redis" || c.Cache.RedisURL != "", "cache.redis_url (CACHE_REDIS_URL) is required for cache.backend redis")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	check(slices.Contains([]string{"json", "text"}, strings.ToLower(c.Log.Format)), "log.format must be json or text, got %q", c.Log.Format)
+	_, err := tracing.ParseExporter(c.Tracing.Exporter)
+	check(err == nil, "tracing.exporter must be none, otlp or stdout, got %q", c.Tracing.Exporter)
+
+	return errors.Join(errs...)
+}
+
+// referrerPolicies are the values browsers understand for Referrer-Policy.
+var referrerPolicies = []string{
+	"no-referrer",
+	"no-referrer-when-downgrade",
+	"origin",
+	"origin-when-cross-origin",
+	"same-origin",
+	"strict-origin",
+	"strict-origin-when-cross-origin",
+	"unsafe-url",
+}
+
+// validOrigin reports whether origin is "*" or a bare http(s) origin, which
+// is all browsers send in the Origin header.
+func validOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+// checkSecret rejects secrets that are short, repetitive or copied from the
+// documentation.
+func checkSecret(secret string) error {
+	const hint = "generate one with `openssl rand -base64 64`"
+	if secret == "" {
+		return fmt.Errorf("auth.secret (SECRET) is required; %s", hint)
+	}
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("auth.secret must be at least %d bytes, got %d; %s", MinSecretLength, len(secret), hint)
+	}
+	for _, weak := range weakSecrets {
+		if strings.Contains(strings.ToLower(secret), weak) {
+			return fmt.Errorf("auth.secret contains the placeholder %q; %s", weak, hint)
+		}
+	}
+	distinct := map[rune]bool{}
+	for _, r := range secret {
+		distinct[r] = true
+	}
+	if len(distinct) < 10 {
+		return fmt.Errorf("auth.secret is too repetitive; %s", hint)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Package cors lets browser clients served from other origins call the API,
+// answering preflight requests and adding the Access-Control headers that
+// tell the browser which origins may read responses.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+)
+
+type Options struct {
+	// AllowedOrigins are scheme://host[:port] origins, or "*" for any
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are the request headers clients may send
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read beyond the
+	// CORS-safelisted ones
+	ExposedHeaders []string
+	// AllowCredentials lets requests carry cookies and HTTP authentication.
+	// It can't be combined with "*", which would hand any site the session.
+	AllowCredentials bool
+	// MaxAge is how long browsers may reuse a preflight answer
+	MaxAge time.Duration
+}
+
+// Middleware answers preflight requests from allowed origins and adds the
+// CORS headers to their other requests. Requests from other origins are
+// served without them, so browsers keep the responses from their scripts.
+// With no allowed origins, next is returned unchanged.
+func Middleware(opts Options, next http.Handler) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		return next
+	}
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+
+	allowOrigin := func(origin string) (string, bool) {
+		if anyOrigin && !opts.AllowCredentials {
+			return "*", true
+		}
+		if anyOrigin || slices.ContainsFunc(opts.AllowedOrigins, func(o string) bool { return strings.EqualFold(o, origin) }) {
+			return origin, true
+		}
+		return "", false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		// The answer depends on the origin unless every origin gets "*"
+		if !anyOrigin || opts.AllowCredentials {
+			httpx.AddVary(h, "Origin")
+		}
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		allowed, ok := allowOrigin(origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			httpx.AddVary(h, "Access-Control-Request-Method")
+			httpx.AddVary(h, "Access-Control-Request-Headers")
+			if ok &&
+				slices.Contains(opts.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) &&
+				headersAllowed(opts.AllowedHeaders, r.Header.Values("Access-Control-Request-Headers")) {
+				h.Set("Access-Control-Allow-Origin", allowed)
+				if opts.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+				h.Set("Access-Control-Allow-Methods", methods)
+				if headers != "" {
+					h.Set("Access-Control-Allow-Headers", headers)
+				}
+				if opts.MaxAge >= time.Second {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+				}
+			}
+			// A refused preflight gets no CORS headers, which the browser
+			// reports to the script as a network error
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if ok {
+			h.Set("Access-Control-Allow-Origin", allowed)
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposed != "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// headersAllowed reports whether every header named in the comma separated
+// requested lists is allowed. Header names are case-insensitive.
+func headersAllowed(allowed, requested []string) bool {
+	for _, list := range requested {
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, name) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
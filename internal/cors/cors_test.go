@@ -0,0 +1,188 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	opts := Options{
+		AllowedOrigins:   []string{"https://client.example.com"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		method      string
+		headers     map[string]string
+		wantCode    int
+		wantHeaders map[string]string
+		wantVary    []string
+	}{
+		{
+			name:     "Test 1: Same Origin",
+			opts:     opts,
+			method:   "GET",
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:     "Test 2: Allowed Origin",
+			opts:     opts,
+			method:   "GET",
+			headers:  map[string]string{"Origin": "https://client.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://client.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "ETag",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:     "Test 3: Other Origin",
+			opts:     opts,
+			method:   "GET",
+			headers:  map[string]string{"Origin": "https://evil.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "",
+				"Access-Control-Allow-Credentials": "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:   "Test 4: Preflight",
+			opts:   opts,
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://client.example.com",
+				"Access-Control-Request-Method":  "DELETE",
+				"Access-Control-Request-Headers": "authorization, content-type",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://client.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST, DELETE",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Max-Age":           "600",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "Test 5: Preflight Method Refused",
+			opts:   opts,
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                        "https://client.example.com",
+				"Access-Control-Request-Method": "PATCH",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "Test 6: Preflight Header Refused",
+			opts:   opts,
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                         "https://client.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Custom",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "Test 7: Preflight From Other Origin",
+			opts:   opts,
+			method: "OPTIONS",
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantCode: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:     "Test 8: Plain OPTIONS Passes Through",
+			opts:     opts,
+			method:   "OPTIONS",
+			headers:  map[string]string{"Origin": "https://client.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://client.example.com",
+				"Access-Control-Allow-Methods": "",
+			},
+			wantVary: []string{"Origin"},
+		},
+		{
+			name:     "Test 9: Any Origin",
+			opts:     Options{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}},
+			method:   "GET",
+			headers:  map[string]string{"Origin": "https://anyone.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:     "Test 10: Disabled",
+			opts:     Options{},
+			method:   "GET",
+			headers:  map[string]string{"Origin": "https://client.example.com"},
+			wantCode: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Middleware(tt.opts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, "/api/chirps", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got: %v; want: %v", rec.Code, tt.wantCode)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s got: %q; want: %q", name, got, want)
+				}
+			}
+			if got := rec.Header().Values("Vary"); fmt.Sprint(got) != fmt.Sprint(tt.wantVary) {
+				t.Errorf("Vary got: %v; want: %v", got, tt.wantVary)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,7 +22,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, hidden_at, hidden_reason
 `
 
 type CreateChirpParams struct {
@@ -37,6 +39,8 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.HiddenReason,
 	)
 	return i, err
 }
@@ -52,12 +56,18 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.hidden_at, chirps.hidden_reason FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= NOW())
+AND (NOT users.shadowbanned OR users.id = $1)
+ORDER BY chirps.created_at ASC
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+// Chirps from suspended authors are hidden from everyone, and chirps from
+// shadowbanned authors are only shown to the author themselves.
+func (q *Queries) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +81,8 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.HiddenReason,
 		); err != nil {
 			return nil, err
 		}
@@ -86,13 +98,21 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getAllChirpsByUser = `-- name: GetAllChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.hidden_at, chirps.hidden_reason FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = $1 AND chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= NOW())
+AND (NOT users.shadowbanned OR users.id = $2)
+ORDER BY chirps.created_at ASC
 `
 
-func (q *Queries) GetAllChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsByUser, userID)
+type GetAllChirpsByUserParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetAllChirpsByUser(ctx context.Context, arg GetAllChirpsByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsByUser, arg.UserID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +126,8 @@ func (q *Queries) GetAllChirpsByUser(ctx context.Context, userID uuid.UUID) ([]C
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.HiddenAt,
+			&i.HiddenReason,
 		); err != nil {
 			return nil, err
 		}
@@ -121,7 +143,7 @@ func (q *Queries) GetAllChirpsByUser(ctx context.Context, userID uuid.UUID) ([]C
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, hidden_at, hidden_reason FROM chirps
 WHERE id = $1 LIMIT 1
 `
 
@@ -134,6 +156,100 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.HiddenAt,
+		&i.HiddenReason,
 	)
 	return i, err
 }
+
+const getChirpsChangedAt = `-- name: GetChirpsChangedAt :one
+SELECT GREATEST(
+    (SELECT changed_at FROM chirp_changes),
+    (SELECT MAX(suspended_until) FROM users WHERE suspended_until <= NOW())
+)::timestamp AS changed_at
+`
+
+// A suspension that runs out changes the lists without any write, so its end
+// counts as a change too.
+func (q *Queries) GetChirpsChangedAt(ctx context.Context) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getChirpsChangedAt)
+	var changed_at time.Time
+	err := row.Scan(&changed_at)
+	return changed_at, err
+}
+
+const getRecentChirpsByUser = `-- name: GetRecentChirpsByUser :many
+SELECT id, created_at, updated_at, body, user_id, hidden_at, hidden_reason FROM chirps
+WHERE user_id = $1 AND created_at >= $2
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type GetRecentChirpsByUserParams struct {
+	UserID   uuid.UUID
+	Since    sql.NullTime
+	RowLimit int32
+}
+
+// Includes hidden chirps so a held chirp cannot simply be posted again.
+func (q *Queries) GetRecentChirpsByUser(ctx context.Context, arg GetRecentChirpsByUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentChirpsByUser, arg.UserID, arg.Since, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.HiddenAt,
+			&i.HiddenReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = COALESCE(hidden_at, NOW()),
+    hidden_reason = $2
+WHERE id = $1
+`
+
+type HideChirpParams struct {
+	ID           uuid.UUID
+	HiddenReason sql.NullString
+}
+
+func (q *Queries) HideChirp(ctx context.Context, arg HideChirpParams) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, arg.ID, arg.HiddenReason)
+	return err
+}
+
+const unhideChirp = `-- name: UnhideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = NULL,
+    hidden_reason = NULL
+WHERE id = $1
+`
+
+func (q *Queries) UnhideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unhideChirp, id)
+	return err
+}
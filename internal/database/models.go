@@ -6,25 +6,115 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ActorID    uuid.NullUUID
+	Action     string
+	TargetType string
+	TargetID   string
+	Ip         string
+	UserAgent  string
+	Diff       json.RawMessage
+}
+
 type Chirp struct {
-	ID        uuid.UUID
-	CreatedAt sql.NullTime
+	ID           uuid.UUID
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+	Body         string
+	UserID       uuid.UUID
+	HiddenAt     sql.NullTime
+	HiddenReason sql.NullString
+}
+
+type ChirpChange struct {
+	ID        bool
+	ChangedAt time.Time
+}
+
+type ChirpReport struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	ChirpID    uuid.UUID
+	ReporterID uuid.NullUUID
+	Reason     string
+	Details    string
+	ResolvedAt sql.NullTime
+}
+
+type LoginAttempt struct {
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.UUID
+	ChirpID     uuid.UUID
+	AuthorID    uuid.UUID
+	Action      string
+	Note        string
+}
+
+type PersonalAccessToken struct {
+	ID          uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	Name        string
+	TokenPrefix string
+	TokenHash   string
+	Scopes      []string
+	ExpiresAt   sql.NullTime
+	LastUsedAt  sql.NullTime
+	RevokedAt   sql.NullTime
+	UserID      uuid.UUID
+}
+
+type ProfanityWord struct {
+	Locale    string
+	Word      string
+	Action    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
 	UpdatedAt sql.NullTime
-	Body      string
-	UserID    uuid.UUID
+	FullAt    time.Time
 }
 
 type RefreshToken struct {
-	Token     string
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
-	ExpiresAt sql.NullTime
-	RevokedAt sql.NullTime
-	UserID    uuid.UUID
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+	ExpiresAt   sql.NullTime
+	RevokedAt   sql.NullTime
+	UserID      uuid.UUID
+	ID          uuid.UUID
+	TokenPrefix string
+	TokenHash   string
+}
+
+type SpamDecision struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	AuthorID  uuid.UUID
+	ChirpID   uuid.NullUUID
+	Body      string
+	Verdict   string
+	Score     int32
+	Signals   []string
 }
 
 type User struct {
@@ -34,4 +124,7 @@ type User struct {
 	Email          string
 	HashedPassword sql.NullString
 	IsChirpyRed    sql.NullBool
+	Role           string
+	SuspendedUntil sql.NullTime
+	Shadowbanned   bool
 }
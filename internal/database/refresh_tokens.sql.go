@@ -13,56 +13,83 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, revoked_at, user_id)
+INSERT INTO refresh_tokens (id, token_prefix, token_hash, created_at, updated_at, expires_at, revoked_at, user_id)
 VALUES (
+    gen_random_uuid(),
     $1,
+    $2,
     NOW(),
     NOW(),
-    $2,
+    $3,
     NULL,
-    $3
+    $4
 )
-RETURNING token, created_at, updated_at, expires_at, revoked_at, user_id
+RETURNING created_at, updated_at, expires_at, revoked_at, user_id, id, token_prefix, token_hash
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
-	ExpiresAt sql.NullTime
-	UserID    uuid.UUID
+	TokenPrefix string
+	TokenHash   string
+	ExpiresAt   sql.NullTime
+	UserID      uuid.UUID
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.ExpiresAt, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenPrefix,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.UserID,
+	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.RevokedAt,
 		&i.UserID,
+		&i.ID,
+		&i.TokenPrefix,
+		&i.TokenHash,
 	)
 	return i, err
 }
 
-const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT token, created_at, updated_at, expires_at, revoked_at, user_id FROM refresh_tokens
-WHERE $1 = token
-LIMIT 1
+const getRefreshTokensByPrefix = `-- name: GetRefreshTokensByPrefix :many
+SELECT created_at, updated_at, expires_at, revoked_at, user_id, id, token_prefix, token_hash FROM refresh_tokens
+WHERE $1 = token_prefix
 `
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
-	var i RefreshToken
-	err := row.Scan(
-		&i.Token,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.ExpiresAt,
-		&i.RevokedAt,
-		&i.UserID,
-	)
-	return i, err
+func (q *Queries) GetRefreshTokensByPrefix(ctx context.Context, tokenPrefix string) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, getRefreshTokensByPrefix, tokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.UserID,
+			&i.ID,
+			&i.TokenPrefix,
+			&i.TokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
@@ -70,16 +97,16 @@ UPDATE refresh_tokens
 SET
     revoked_at = $2,
     updated_at = $3
-WHERE $1 = token
+WHERE $1 = id
 `
 
 type RevokeRefreshTokenParams struct {
-	Token     string
+	ID        uuid.UUID
 	RevokedAt sql.NullTime
 	UpdatedAt sql.NullTime
 }
 
 func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, arg.Token, arg.RevokedAt, arg.UpdatedAt)
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, arg.ID, arg.RevokedAt, arg.UpdatedAt)
 	return err
 }
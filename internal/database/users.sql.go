@@ -21,7 +21,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned
 `
 
 type CreateUserParams struct {
@@ -39,6 +39,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
 	)
 	return i, err
 }
@@ -52,8 +55,31 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned FROM users
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+	)
+	return i, err
+}
+
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned FROM users
 WHERE $1 = email
 LIMIT 1
 `
@@ -68,10 +94,99 @@ func (q *Queries) GetUserByUsername(ctx context.Context, email string) (User, er
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+	)
+	return i, err
+}
+
+const promoteFirstAdmin = `-- name: PromoteFirstAdmin :execrows
+UPDATE users
+SET
+    role = 'admin',
+    updated_at = NOW()
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM users WHERE role = 'admin'
+)
+`
+
+func (q *Queries) PromoteFirstAdmin(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, promoteFirstAdmin, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE $1 = id
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
 	)
 	return i, err
 }
 
+const setUserShadowbanned = `-- name: SetUserShadowbanned :exec
+UPDATE users
+SET
+    shadowbanned = $2,
+    updated_at = NOW()
+WHERE $1 = id
+`
+
+type SetUserShadowbannedParams struct {
+	ID           uuid.UUID
+	Shadowbanned bool
+}
+
+func (q *Queries) SetUserShadowbanned(ctx context.Context, arg SetUserShadowbannedParams) error {
+	_, err := q.db.ExecContext(ctx, setUserShadowbanned, arg.ID, arg.Shadowbanned)
+	return err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+UPDATE users
+SET
+    suspended_until = $2,
+    updated_at = NOW()
+WHERE $1 = id
+`
+
+type SuspendUserParams struct {
+	ID             uuid.UUID
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -79,7 +194,7 @@ SET
     hashed_password = $3,
     updated_at = NOW() 
 WHERE $1 = id
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned
 `
 
 type UpdateUserParams struct {
@@ -98,6 +213,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
 	)
 	return i, err
 }
@@ -107,7 +225,7 @@ UPDATE users
 SET 
     is_chirpy_red = TRUE
 WHERE $1 = id
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned
 `
 
 func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
@@ -120,6 +238,9 @@ func (q *Queries) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
 	)
 	return i, err
 }
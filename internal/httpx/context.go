@@ -0,0 +1,15 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+// ServeWithContext calls next with a copy of r carrying ctx. ServeMux records
+// the matched route on the request it is given, so the pattern is copied back
+// to r for middleware further out that labels by route.
+func ServeWithContext(ctx context.Context, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	inner := r.WithContext(ctx)
+	next.ServeHTTP(w, inner)
+	r.Pattern = inner.Pattern
+}
@@ -0,0 +1,18 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// AddVary adds field to the Vary header unless it is listed already.
+func AddVary(h http.Header, field string) {
+	for _, value := range h.Values("Vary") {
+		for _, listed := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
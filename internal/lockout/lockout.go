@@ -0,0 +1,185 @@
+// Package lockout tracks failed login attempts per account and per client IP
+// and decides how long further attempts have to wait.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Attempts is the failure history stored for a single key.
+type Attempts struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Store keeps failure counters. The in-memory store is enough for a single
+// replica; the Postgres store shares counters across replicas.
+type Store interface {
+	Get(ctx context.Context, key string) (Attempts, error)
+	// RecordFailure adds a failure for key and returns the updated count.
+	// Failures older than windowStart are forgotten first.
+	RecordFailure(ctx context.Context, key string, failedAt, windowStart time.Time) (int, error)
+	LockUntil(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy describes how quickly failures turn into delays for one kind of key.
+type Policy struct {
+	// FreeAttempts failures are allowed before any delay is applied.
+	FreeAttempts int
+	// BaseDelay doubles with each failure past FreeAttempts, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter failures lock the key for LockoutDuration.
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// Window is how long failures are remembered after the most recent one.
+	Window time.Duration
+}
+
+var DefaultAccountPolicy = Policy{
+	FreeAttempts:    5,
+	BaseDelay:       time.Second,
+	MaxDelay:        15 * time.Minute,
+	LockoutAfter:    10,
+	LockoutDuration: 30 * time.Minute,
+	Window:          time.Hour,
+}
+
+// DefaultIPPolicy is looser than the account policy since many users can
+// share an address behind NAT.
+var DefaultIPPolicy = Policy{
+	FreeAttempts:    20,
+	BaseDelay:       time.Second,
+	MaxDelay:        15 * time.Minute,
+	LockoutAfter:    100,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+// delay returns how long the key is blocked after its nth failure.
+func (p Policy) delay(failures int) time.Duration {
+	if failures >= p.LockoutAfter {
+		return p.LockoutDuration
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	d := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		d *= 2
+		if d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(d, p.MaxDelay)
+}
+
+// Result reports the state after a failed attempt has been recorded.
+type Result struct {
+	// RetryAfter is how long the client has to wait before trying again.
+	RetryAfter time.Duration
+	// AccountLockedOut is true only for the failure that crossed the account
+	// lockout threshold, so callers notify the user once.
+	AccountLockedOut   bool
+	AccountLockedUntil time.Time
+}
+
+type Guard struct {
+	store         Store
+	accountPolicy Policy
+	ipPolicy      Policy
+	now           func() time.Time
+}
+
+func NewGuard(store Store, accountPolicy, ipPolicy Policy) *Guard {
+	return &Guard{
+		store:         store,
+		accountPolicy: accountPolicy,
+		ipPolicy:      ipPolicy,
+		now:           time.Now,
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + email
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long the caller has to wait before a login attempt for
+// email from ip is allowed. Zero means the attempt may proceed.
+func (g *Guard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := g.now()
+	retryAfter := time.Duration(0)
+
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		attempts, err := g.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if attempts.LockedUntil.After(now) {
+			retryAfter = max(retryAfter, attempts.LockedUntil.Sub(now))
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordFailure counts a failed attempt against both the account and the IP.
+func (g *Guard) RecordFailure(ctx context.Context, email, ip string) (Result, error) {
+	now := g.now()
+	result := Result{}
+
+	accountUntil, accountFailures, err := g.recordFailure(ctx, accountKey(email), g.accountPolicy, now)
+	if err != nil {
+		return Result{}, err
+	}
+	if accountFailures == g.accountPolicy.LockoutAfter {
+		result.AccountLockedOut = true
+		result.AccountLockedUntil = accountUntil
+	}
+
+	ipUntil, _, err := g.recordFailure(ctx, ipKey(ip), g.ipPolicy, now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	for _, until := range []time.Time{accountUntil, ipUntil} {
+		if until.After(now) {
+			result.RetryAfter = max(result.RetryAfter, until.Sub(now))
+		}
+	}
+
+	return result, nil
+}
+
+func (g *Guard) recordFailure(ctx context.Context, key string, policy Policy, now time.Time) (time.Time, int, error) {
+	failures, err := g.store.RecordFailure(ctx, key, now, now.Add(-policy.Window))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	d := policy.delay(failures)
+	if d == 0 {
+		return time.Time{}, failures, nil
+	}
+
+	until := now.Add(d)
+	err = g.store.LockUntil(ctx, key, until)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return until, failures, nil
+}
+
+// RecordSuccess clears the account's counter. The IP counter is kept so one
+// valid account cannot be used to reset an address that is guessing others.
+func (g *Guard) RecordSuccess(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
@@ -0,0 +1,115 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: time.Hour,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "Test 1: Free Attempt", failures: 3, want: 0},
+		{name: "Test 2: First Delayed Attempt", failures: 4, want: time.Second},
+		{name: "Test 3: Delay Doubles", failures: 6, want: 4 * time.Second},
+		{name: "Test 4: Delay Capped", failures: 9, want: 10 * time.Second},
+		{name: "Test 5: Lockout", failures: 10, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := policy.delay(tt.failures)
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	accountPolicy := Policy{
+		FreeAttempts:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    4,
+		LockoutDuration: 30 * time.Minute,
+		Window:          time.Hour,
+	}
+	ipPolicy := accountPolicy
+	ipPolicy.FreeAttempts = 100
+	ipPolicy.LockoutAfter = 1000
+
+	g := NewGuard(NewMemoryStore(), accountPolicy, ipPolicy)
+	g.now = func() time.Time { return now }
+
+	for i := 1; i <= 2; i++ {
+		result, err := g.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+		if err != nil {
+			t.Fatalf("RecordFailure errored: %v", err)
+		}
+		if result.RetryAfter != 0 {
+			t.Fatalf("failure %d: got retry after %v; want none", i, result.RetryAfter)
+		}
+	}
+
+	result, err := g.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("RecordFailure errored: %v", err)
+	}
+	if result.RetryAfter != time.Second || result.AccountLockedOut {
+		t.Fatalf("got %+v; want 1s backoff without lockout", result)
+	}
+
+	retryAfter, err := g.Check(ctx, "user@example.com", "10.0.0.2")
+	if err != nil {
+		t.Fatalf("Check errored: %v", err)
+	}
+	if retryAfter != time.Second {
+		t.Errorf("account backoff should apply from any IP, got %v", retryAfter)
+	}
+
+	retryAfter, _ = g.Check(ctx, "other@example.com", "10.0.0.2")
+	if retryAfter != 0 {
+		t.Errorf("other accounts should not be delayed, got %v", retryAfter)
+	}
+
+	now = now.Add(2 * time.Second)
+	result, _ = g.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	if !result.AccountLockedOut || result.RetryAfter != 30*time.Minute {
+		t.Fatalf("got %+v; want 30m lockout", result)
+	}
+
+	now = now.Add(time.Second)
+	result, _ = g.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	if result.AccountLockedOut {
+		t.Errorf("lockout should only be reported once")
+	}
+
+	if err := g.RecordSuccess(ctx, "user@example.com"); err != nil {
+		t.Fatalf("RecordSuccess errored: %v", err)
+	}
+	retryAfter, _ = g.Check(ctx, "user@example.com", "10.0.0.1")
+	if retryAfter != 0 {
+		t.Errorf("success should clear the account lock, got %v", retryAfter)
+	}
+
+	now = now.Add(2 * time.Hour)
+	result, _ = g.RecordFailure(ctx, "user@example.com", "10.0.0.1")
+	if result.RetryAfter != 0 {
+		t.Errorf("failures outside the window should be forgotten, got %v", result.RetryAfter)
+	}
+}
@@ -0,0 +1,77 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes pass between removals of forgotten keys.
+const sweepEvery = 1000
+
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+	writes   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		attempts: map[string]Attempts{},
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[key], nil
+}
+
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, failedAt, windowStart time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[key]
+	if a.LastFailureAt.Before(windowStart) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = failedAt
+	s.attempts[key] = a
+
+	s.writes++
+	if s.writes%sweepEvery == 0 {
+		s.sweep(windowStart)
+	}
+
+	return a.Failures, nil
+}
+
+func (s *MemoryStore) LockUntil(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[key]
+	a.LockedUntil = until
+	s.attempts[key] = a
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// sweep drops keys whose failures have aged out and that are not locked.
+// Callers must hold s.mu.
+func (s *MemoryStore) sweep(windowStart time.Time) {
+	for key, a := range s.attempts {
+		if a.LastFailureAt.Before(windowStart) && a.LockedUntil.Before(windowStart) {
+			delete(s.attempts, key)
+		}
+	}
+}
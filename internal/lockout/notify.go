@@ -0,0 +1,60 @@
+package lockout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Notifier tells a user that their account has been temporarily locked.
+type Notifier interface {
+	NotifyLockout(ctx context.Context, email string, lockedUntil time.Time) error
+}
+
+// WebhookNotifier posts lockout events to an external service, which is
+// responsible for actually reaching the user (e.g. by email).
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) NotifyLockout(ctx context.Context, email string, lockedUntil time.Time) error {
+	type lockoutEvent struct {
+		Event       string    `json:"event"`
+		Email       string    `json:"email"`
+		LockedUntil time.Time `json:"locked_until"`
+	}
+
+	body, err := json.Marshal(lockoutEvent{
+		Event:       "user.locked_out",
+		Email:       email,
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("lockout webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+)
+
+type PostgresStore struct {
+	db *database.Queries
+}
+
+func NewPostgresStore(db *database.Queries) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (Attempts, error) {
+	row, err := s.db.GetLoginAttempt(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Attempts{}, nil
+	}
+	if err != nil {
+		return Attempts{}, err
+	}
+
+	return Attempts{
+		Failures:      int(row.Failures),
+		LastFailureAt: row.LastFailureAt,
+		LockedUntil:   row.LockedUntil.Time,
+	}, nil
+}
+
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, failedAt, windowStart time.Time) (int, error) {
+	row, err := s.db.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    failedAt,
+		WindowStart: windowStart,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(row.Failures), nil
+}
+
+func (s *PostgresStore) LockUntil(ctx context.Context, key string, until time.Time) error {
+	return s.db.LockLoginAttempt(ctx, database.LockLoginAttemptParams{
+		Key:         key,
+		LockedUntil: sql.NullTime{Time: until, Valid: true},
+	})
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.db.ResetLoginAttempts(ctx, key)
+}
@@ -0,0 +1,68 @@
+// Package logging sets up log/slog and carries a request-scoped logger, tagged
+// with the request ID and, once known, the user ID, through the context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// New returns a logger writing to w. format is "json" (the default) or
+// "text"; level is "debug", "info" (the default), "warn" or "error".
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if level == "" {
+		level = "info"
+	}
+	err := lvl.UnmarshalText([]byte(level))
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: accepts json or text", format)
+	}
+}
+
+type loggerKey struct{}
+
+// requestInfo is shared by reference between the middleware and the
+// handlers, so values found deep in the chain, like the user ID, reach the
+// access log line.
+type requestInfo struct {
+	userID string
+}
+
+type requestInfoKey struct{}
+
+// WithLogger returns a context carrying l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the request-scoped logger, or the default logger
+// outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithUserID tags the rest of the request's log lines, including its access
+// log line, with the authenticated user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID = userID
+	}
+	return WithLogger(ctx, FromContext(ctx).With("user_id", userID))
+}
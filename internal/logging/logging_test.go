@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		level   string
+		wantErr bool
+	}{
+		{
+			name: "Test 1: Defaults",
+		},
+		{
+			name:   "Test 2: Text Format With Debug Level",
+			format: "text",
+			level:  "debug",
+		},
+		{
+			name:    "Test 3: Unknown Format",
+			format:  "xml",
+			wantErr: true,
+		},
+		{
+			name:    "Test 4: Unknown Level",
+			level:   "loud",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(io.Discard, tt.format, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error: %v; want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "info")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithUserID(r.Context(), "user-1")
+		FromContext(ctx).Info("handler")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := Middleware(logger, mux)
+
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{
+			name:          "Test 1: Propagates Incoming ID",
+			requestID:     "abc-123",
+			wantRequestID: "abc-123",
+		},
+		{
+			name:      "Test 2: Generates Missing ID",
+			requestID: "",
+		},
+		{
+			name:      "Test 3: Replaces Invalid ID",
+			requestID: "bad id\nfake log line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", "/api/chirps/1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get(RequestIDHeader)
+			if tt.wantRequestID != "" && requestID != tt.wantRequestID {
+				t.Errorf("got: %v; want: %v", requestID, tt.wantRequestID)
+			}
+			if !validRequestID.MatchString(requestID) {
+				t.Errorf("response has invalid request ID %q", requestID)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d log lines; want 2", len(lines))
+			}
+			for _, line := range lines {
+				entry := map[string]any{}
+				if err := json.Unmarshal([]byte(line), &entry); err != nil {
+					t.Fatalf("log line is not JSON: %v", err)
+				}
+				if entry["request_id"] != requestID {
+					t.Errorf("got: %v; want: %v", entry["request_id"], requestID)
+				}
+				if entry["user_id"] != "user-1" {
+					t.Errorf("got: %v; want: %v", entry["user_id"], "user-1")
+				}
+			}
+
+			access := map[string]any{}
+			json.Unmarshal([]byte(lines[1]), &access)
+			if access["route"] != "GET /api/chirps/{chirpID}" {
+				t.Errorf("got: %v; want: %v", access["route"], "GET /api/chirps/{chirpID}")
+			}
+			if access["status"] != float64(http.StatusTeapot) {
+				t.Errorf("got: %v; want: %v", access["status"], http.StatusTeapot)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	})
+	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("created"))
+	})
+	handler := m.Middleware(mux)
+
+	for _, path := range []string{"/api/chirps/1", "/api/chirps/2", "/random/path"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/chirps", nil))
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   float64
+	}{
+		{
+			name:   "Test 1: Route Pattern Instead Of Path",
+			labels: []string{"GET", "/api/chirps/{chirpID}", "404"},
+			want:   2,
+		},
+		{
+			name:   "Test 2: Implicit 200",
+			labels: []string{"POST", "/api/chirps", "200"},
+			want:   1,
+		},
+		{
+			name:   "Test 3: Unmatched Path",
+			labels: []string{"GET", unmatchedRoute, "404"},
+			want:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := testutil.ToFloat64(m.requests.WithLabelValues(tt.labels...))
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+
+	if inFlight := testutil.ToFloat64(m.inFlight); inFlight != 0 {
+		t.Errorf("got %v requests in flight; want 0", inFlight)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.Logins.WithLabelValues("success").Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{`chirpy_logins_total{result="success"} 1`, "go_goroutines", "process_"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output is missing %q", want)
+		}
+	}
+}
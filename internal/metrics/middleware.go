@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+)
+
+// unmatchedRoute labels requests no route matched, so scanners probing random
+// paths cannot create a new series per path.
+const unmatchedRoute = "unmatched"
+
+// Middleware records request metrics. It must wrap the ServeMux so the route
+// pattern the mux matched is known once the request has been served.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		rec := httpx.NewResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		route := routeLabel(r.Pattern)
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(rec.Status())).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		m.responseSize.WithLabelValues(r.Method, route).Observe(float64(rec.Bytes()))
+	})
+}
+
+// routeLabel strips the method from a pattern such as "GET /api/chirps/{chirpID}",
+// since the method has its own label.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+	return pattern
+}
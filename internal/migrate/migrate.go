@@ -0,0 +1,62 @@
+// Package migrate applies the goose migrations embedded from sql/schema.
+// Commands that change the schema hold a Postgres advisory lock, so replicas
+// starting together apply each migration once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	"github.com/mattnickolaus/chirpy/sql/schema"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Latest is the version of the newest embedded migration, which the running
+// binary expects the database to be at.
+func Latest() (int64, error) {
+	return LatestIn(schema.FS)
+}
+
+// LatestIn is Latest for the migrations in fsys, such as the SQLite ones.
+func LatestIn(fsys fs.FS) (int64, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, name := range names {
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", name, err)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
+// NewProvider returns a goose provider for the embedded migrations. Up is
+// safe to run from several replicas at once: the others wait for the lock and
+// then find nothing left to apply.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, schema.FS, goose.WithSessionLocker(locker))
+}
+
+// Redo rolls back the latest migration and applies it again.
+func Redo(ctx context.Context, p *goose.Provider) ([]*goose.MigrationResult, error) {
+	down, err := p.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+	up, err := p.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+	return []*goose.MigrationResult{down, up}, nil
+}
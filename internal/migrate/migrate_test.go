@@ -0,0 +1,39 @@
+package migrate
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/mattnickolaus/chirpy/sql/schema"
+	"github.com/pressly/goose/v3"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(schema.FS, "*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	// Versions must run 1, 2, 3... so a skipped or duplicated number is caught
+	// before it reaches a database
+	for i, name := range names {
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			t.Fatalf("migration %s: %v", name, err)
+		}
+		if version != int64(i+1) {
+			t.Errorf("%s: got: %v; want: %v", name, version, i+1)
+		}
+	}
+
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != int64(len(names)) {
+		t.Errorf("got: %v; want: %v", latest, len(names))
+	}
+}
@@ -0,0 +1,272 @@
+// Package profanity finds blocked words in chirps. Text is split on Unicode
+// word boundaries and every word is normalized (case, diacritics and common
+// leetspeak) before it is compared with the configured word lists.
+package profanity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Action is what happens to a chirp that contains a listed word.
+type Action string
+
+const (
+	// ActionNone means no listed word was found.
+	ActionNone Action = ""
+	// ActionMask replaces the word with asterisks.
+	ActionMask Action = "mask"
+	// ActionFlag masks the word and queues the chirp for moderator review.
+	ActionFlag Action = "flag"
+	// ActionReject refuses the chirp.
+	ActionReject Action = "reject"
+)
+
+var actionSeverity = map[Action]int{
+	ActionNone:   0,
+	ActionMask:   1,
+	ActionFlag:   2,
+	ActionReject: 3,
+}
+
+func ParseAction(s string) (Action, error) {
+	action := Action(s)
+	if action == ActionNone {
+		return "", fmt.Errorf("action is required")
+	}
+	if _, ok := actionSeverity[action]; !ok {
+		return "", fmt.Errorf("unknown action: %q", s)
+	}
+	return action, nil
+}
+
+// DefaultLocale is the locale of chirps that don't name one.
+const DefaultLocale = "en"
+
+// Word is a single entry of a locale's word list.
+type Word struct {
+	Locale string
+	Word   string
+	Action Action
+}
+
+// DefaultWords is the built-in list every filter starts with.
+var DefaultWords = []Word{
+	{Locale: "en", Word: "kerfuffle", Action: ActionMask},
+	{Locale: "en", Word: "sharbert", Action: ActionMask},
+	{Locale: "en", Word: "fornax", Action: ActionMask},
+}
+
+const mask = "****"
+
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+}
+
+// letterFolds spells out letters that are not a base letter plus a combining
+// mark, so stripping diacritics leaves them unchanged.
+var letterFolds = map[rune]string{
+	'ø': "o",
+	'æ': "ae",
+	'œ': "oe",
+	'ß': "ss",
+	'ð': "d",
+	'đ': "d",
+	'þ': "th",
+	'ł': "l",
+	'ı': "i",
+	'ħ': "h",
+}
+
+// Normalize folds a word to the form lists are compared in: lower case,
+// without diacritics, with letters such as ø and ß spelled out and with
+// leetspeak digits and symbols replaced.
+func Normalize(word string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), word)
+	if err != nil {
+		stripped = word
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(stripped) {
+		if folded, ok := letterFolds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		if replacement, ok := leetspeak[r]; ok {
+			r = replacement
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// normalizeLocale lower-cases a locale tag and uses "-" between its subtags,
+// so "de_AT" and "de-at" name the same list.
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+
+// lookupLocales returns the lists a chirp in locale is checked against: the
+// locale itself, its language, so a "de-at" chirp also uses "de", and always
+// DefaultLocale. The locale comes from the client, so naming one without a
+// list must not skip the default list.
+func lookupLocales(locale string) []string {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	locales := []string{locale}
+	if language, _, ok := strings.Cut(locale, "-"); ok {
+		locales = append(locales, language)
+	}
+	if !slices.Contains(locales, DefaultLocale) {
+		locales = append(locales, DefaultLocale)
+	}
+	return locales
+}
+
+// isWordRune reports whether r belongs inside a word. Leetspeak symbols count
+// as word characters so "$harbert" stays one word.
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+		return true
+	}
+	_, ok := leetspeak[r]
+	return ok
+}
+
+type token struct {
+	start, end int
+}
+
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// Result describes what the filter found in a chirp.
+type Result struct {
+	// Text is the chirp with every listed word masked.
+	Text string
+	// Action is the most severe action of all matched words.
+	Action Action
+	// Matches are the original words that were found.
+	Matches []string
+}
+
+type wordKey struct {
+	locale, word string
+}
+
+// Filter holds the merged word lists. It is safe for concurrent use and can be
+// swapped out with Replace while requests are being served.
+type Filter struct {
+	mu    sync.RWMutex
+	words map[wordKey]Action
+}
+
+func NewFilter(words []Word) *Filter {
+	f := &Filter{}
+	f.Replace(words)
+	return f
+}
+
+// Replace swaps in new word lists. Each locale keeps its own list. When a word
+// appears more than once in a locale, the later entry wins so database
+// overrides take precedence over files.
+func (f *Filter) Replace(words []Word) {
+	merged := map[wordKey]Action{}
+	for _, w := range words {
+		normalized := Normalize(strings.TrimSpace(w.Word))
+		if normalized == "" {
+			continue
+		}
+		merged[wordKey{locale: normalizeLocale(w.Locale), word: normalized}] = w.Action
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.words = merged
+}
+
+// Check masks the words of text found on the lists for locale and on the
+// DefaultLocale list.
+func (f *Filter) Check(locale, text string) Result {
+	locales := lookupLocales(locale)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	result := Result{Action: ActionNone}
+	var b strings.Builder
+	last := 0
+	for _, t := range tokenize(text) {
+		word := text[t.start:t.end]
+		action, listed := f.lookup(locales, Normalize(word))
+		if !listed {
+			continue
+		}
+
+		result.Matches = append(result.Matches, word)
+		if actionSeverity[action] > actionSeverity[result.Action] {
+			result.Action = action
+		}
+		b.WriteString(text[last:t.start])
+		b.WriteString(mask)
+		last = t.end
+	}
+	b.WriteString(text[last:])
+
+	result.Text = b.String()
+	return result
+}
+
+// lookup returns the most severe action any of locales lists word with, so
+// naming a locale can only add to the default list, never soften it.
+func (f *Filter) lookup(locales []string, word string) (Action, bool) {
+	found := ActionNone
+	listed := false
+	for _, locale := range locales {
+		action, ok := f.words[wordKey{locale: locale, word: word}]
+		if !ok {
+			continue
+		}
+		listed = true
+		if actionSeverity[action] > actionSeverity[found] {
+			found = action
+		}
+	}
+	return found, listed
+}
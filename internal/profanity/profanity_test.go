@@ -0,0 +1,200 @@
+package profanity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Test 1: Mixed Case",
+			input: "KerFuffle",
+			want:  "kerfuffle",
+		},
+		{
+			name:  "Test 2: Leetspeak",
+			input: "$h4rb3r7",
+			want:  "sharbert",
+		},
+		{
+			name:  "Test 3: Diacritics",
+			input: "Fórnäx",
+			want:  "fornax",
+		},
+		{
+			name:  "Test 4: Non Latin Script",
+			input: "ПРИВЕТ",
+			want:  "привет",
+		},
+		{
+			name:  "Test 5: Letters Without Combining Marks",
+			input: "FØRNAX Straße Æsir",
+			want:  "fornax strasse aesir",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Normalize(tt.input)
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterCheck(t *testing.T) {
+	filter := NewFilter([]Word{
+		{Locale: "en", Word: "kerfuffle", Action: ActionMask},
+		{Locale: "en", Word: "sharbert", Action: ActionFlag},
+		{Locale: "de", Word: "fornax", Action: ActionReject},
+		{Locale: "de", Word: "kerfuffle", Action: ActionReject},
+	})
+
+	tests := []struct {
+		name        string
+		locale      string
+		input       string
+		wantText    string
+		wantAction  Action
+		wantMatches []string
+	}{
+		{
+			name:        "Test 1: Clean",
+			input:       "Nothing to see here",
+			wantText:    "Nothing to see here",
+			wantAction:  ActionNone,
+			wantMatches: nil,
+		},
+		{
+			name:        "Test 2: Mask",
+			input:       "Such a Kerfuffle!",
+			wantText:    "Such a ****!",
+			wantAction:  ActionMask,
+			wantMatches: []string{"Kerfuffle"},
+		},
+		{
+			name:        "Test 3: Most Severe Action Wins",
+			locale:      "en",
+			input:       "kerfuffle and $harbert",
+			wantText:    "**** and ****",
+			wantAction:  ActionFlag,
+			wantMatches: []string{"kerfuffle", "$harbert"},
+		},
+		{
+			name:        "Test 4: Flag",
+			input:       "(sharbert)",
+			wantText:    "(****)",
+			wantAction:  ActionFlag,
+			wantMatches: []string{"sharbert"},
+		},
+		{
+			name:        "Test 5: Other Locale's Words Ignored",
+			locale:      "en",
+			input:       "f0rnax",
+			wantText:    "f0rnax",
+			wantAction:  ActionNone,
+			wantMatches: nil,
+		},
+		{
+			name:        "Test 6: Locale Adds To Default List",
+			locale:      "de",
+			input:       "kerfuffle and f0rnax, and sharbert",
+			wantText:    "**** and ****, and ****",
+			wantAction:  ActionReject,
+			wantMatches: []string{"kerfuffle", "f0rnax", "sharbert"},
+		},
+		{
+			name:        "Test 7: Regional Locale Uses Its Language",
+			locale:      "de_AT",
+			input:       "Kerfuffle!",
+			wantText:    "****!",
+			wantAction:  ActionReject,
+			wantMatches: []string{"Kerfuffle"},
+		},
+		{
+			name:        "Test 8: Unknown Locale Uses Default",
+			locale:      "zz",
+			input:       "sharbert",
+			wantText:    "****",
+			wantAction:  ActionFlag,
+			wantMatches: []string{"sharbert"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := filter.Check(tt.locale, tt.input)
+			if result.Text != tt.wantText {
+				t.Errorf("got: %v; want: %v", result.Text, tt.wantText)
+			}
+			if result.Action != tt.wantAction {
+				t.Errorf("got: %v; want: %v", result.Action, tt.wantAction)
+			}
+			if !slices.Equal(result.Matches, tt.wantMatches) {
+				t.Errorf("got: %v; want: %v", result.Matches, tt.wantMatches)
+			}
+		})
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "en.txt"), []byte("# English list\nkerfuffle reject\n\nwombat\n"), 0o600)
+	if err != nil {
+		t.Fatalf("writing word list: %v", err)
+	}
+
+	words, err := DirSource(dir)(context.Background())
+	if err != nil {
+		t.Fatalf("DirSource errored: %v", err)
+	}
+	want := []Word{
+		{Locale: "en", Word: "kerfuffle", Action: ActionReject},
+		{Locale: "en", Word: "wombat", Action: ActionMask},
+	}
+	if !slices.Equal(words, want) {
+		t.Errorf("got: %v; want: %v", words, want)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "fr.txt"), []byte("wombat explode\n"), 0o600)
+	if err != nil {
+		t.Fatalf("writing word list: %v", err)
+	}
+	if _, err := DirSource(dir)(context.Background()); err == nil {
+		t.Errorf("expected error for unknown action")
+	}
+}
+
+func TestFilterReload(t *testing.T) {
+	filter := NewFilter(DefaultWords)
+	override := StaticSource([]Word{{Locale: "en", Word: "kerfuffle", Action: ActionReject}})
+
+	err := filter.Reload(context.Background(), StaticSource(DefaultWords), override)
+	if err != nil {
+		t.Fatalf("Reload errored: %v", err)
+	}
+	if action := filter.Check("en", "kerfuffle").Action; action != ActionReject {
+		t.Errorf("got: %v; want: %v", action, ActionReject)
+	}
+
+	failing := func(ctx context.Context) ([]Word, error) {
+		return nil, errors.New("database unavailable")
+	}
+	err = filter.Reload(context.Background(), StaticSource(DefaultWords), failing)
+	if err == nil {
+		t.Fatalf("expected Reload to fail")
+	}
+	if action := filter.Check("en", "kerfuffle").Action; action != ActionReject {
+		t.Errorf("failed reload must keep the previous lists, got: %v; want: %v", action, ActionReject)
+	}
+}
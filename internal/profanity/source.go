@@ -0,0 +1,132 @@
+package profanity
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source returns one layer of word lists. Sources are merged in order, so a
+// later source can change the action of a word an earlier one listed.
+type Source func(ctx context.Context) ([]Word, error)
+
+// StaticSource always returns the same words.
+func StaticSource(words []Word) Source {
+	return func(ctx context.Context) ([]Word, error) {
+		return words, nil
+	}
+}
+
+// DirSource reads every <locale>.txt file in dir. Each line holds a word and
+// optionally its action, separated by whitespace; the action defaults to mask.
+// Blank lines and lines starting with # are ignored.
+func DirSource(dir string) Source {
+	return func(ctx context.Context) ([]Word, error) {
+		files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+
+		words := []Word{}
+		for _, file := range files {
+			locale := strings.TrimSuffix(filepath.Base(file), ".txt")
+			fileWords, err := readWordFile(file, locale)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, fileWords...)
+		}
+		return words, nil
+	}
+}
+
+func readWordFile(path, locale string) ([]Word, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	words := []Word{}
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		action := ActionMask
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"word [action]\"", path, lineNumber)
+		}
+		if len(fields) == 2 {
+			action, err = ParseAction(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+			}
+		}
+		words = append(words, Word{Locale: locale, Word: fields[0], Action: action})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// Load merges every source. It fails if any source fails so a broken file or
+// database never silently empties the lists.
+func Load(ctx context.Context, sources ...Source) ([]Word, error) {
+	words := []Word{}
+	for _, source := range sources {
+		sourceWords, err := source(ctx)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, sourceWords...)
+	}
+	return words, nil
+}
+
+// Reload loads the sources and swaps them into the filter. On error the
+// filter keeps the lists it had.
+func (f *Filter) Reload(ctx context.Context, sources ...Source) error {
+	words, err := Load(ctx, sources...)
+	if err != nil {
+		return err
+	}
+	f.Replace(words)
+	return nil
+}
+
+// Watch reloads the filter every interval until ctx is done, picking up
+// edited files and changes made by other instances. onReload, if not nil, is
+// called with the result of every reload.
+func (f *Filter) Watch(ctx context.Context, interval time.Duration, onReload func(error), sources ...Source) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := f.Reload(ctx, sources...)
+			if err != nil {
+				slog.Error("Error reloading profanity lists", "error", err)
+			}
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
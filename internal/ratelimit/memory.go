@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes pass between removals of full buckets.
+const sweepEvery = 1000
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+	writes  int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]Bucket{},
+	}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(Bucket) Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := fn(s.buckets[key])
+	s.buckets[key] = b
+
+	s.writes++
+	if s.writes%sweepEvery == 0 {
+		s.sweep(b.UpdatedAt)
+	}
+
+	return nil
+}
+
+// sweep drops buckets that have refilled completely, since a missing bucket
+// is treated as full anyway. Callers must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.FullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
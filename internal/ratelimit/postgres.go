@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+)
+
+// PostgresStore keeps buckets in rate_limit_buckets. Like the memory store it
+// prunes full buckets every sweepEvery writes, counted per replica.
+type PostgresStore struct {
+	db      *sql.DB
+	queries *database.Queries
+	writes  atomic.Int64
+}
+
+func NewPostgresStore(db *sql.DB, queries *database.Queries) *PostgresStore {
+	return &PostgresStore{db: db, queries: queries}
+}
+
+// Update locks the bucket row for the duration of fn, so replicas taking
+// tokens from the same bucket queue up behind each other.
+func (s *PostgresStore) Update(ctx context.Context, key string, fn func(Bucket) Bucket) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.queries.WithTx(tx)
+
+	err = q.EnsureRateLimitBucket(ctx, key)
+	if err != nil {
+		return err
+	}
+	row, err := q.GetRateLimitBucketForUpdate(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	current := Bucket{}
+	if row.UpdatedAt.Valid {
+		current = Bucket{Tokens: row.Tokens, UpdatedAt: row.UpdatedAt.Time}
+	}
+	updated := fn(current)
+
+	err = q.UpdateRateLimitBucket(ctx, database.UpdateRateLimitBucketParams{
+		Key:       key,
+		Tokens:    updated.Tokens,
+		UpdatedAt: sql.NullTime{Time: updated.UpdatedAt, Valid: true},
+		FullAt:    updated.FullAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	if s.writes.Add(1)%sweepEvery == 0 {
+		_, err = s.queries.DeleteFullRateLimitBuckets(ctx, updated.UpdatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error pruning rate limit buckets", "error", err)
+		}
+	}
+	return nil
+}
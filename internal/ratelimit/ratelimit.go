@@ -0,0 +1,157 @@
+// Package ratelimit implements token buckets. Every key gets a bucket that
+// holds up to Limit.Requests tokens and refills evenly over Limit.Per, so
+// clients can burst up to the limit and then continue at the refill rate.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is the size of a bucket and how long it takes to refill completely.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit reads limits written as requests/duration, e.g. "10/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q: want requests/duration, e.g. 10/1m", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid limit %q: requests must be a positive integer", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: duration must be positive", s)
+	}
+	return Limit{Requests: n, Per: d}, nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+func (l Limit) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// rate is the number of tokens added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Policy is the limit applied to one group of routes. Buckets of different
+// policies are kept apart by Name.
+type Policy struct {
+	Name string
+	// Default applies to anonymous callers and free accounts.
+	Default Limit
+	// ChirpyRed applies to Chirpy Red members. A zero limit means members get
+	// the default.
+	ChirpyRed Limit
+}
+
+// LimitFor returns the limit for a caller.
+func (p Policy) LimitFor(chirpyRed bool) Limit {
+	if chirpyRed && p.ChirpyRed.Requests > 0 {
+		return p.ChirpyRed
+	}
+	return p.Default
+}
+
+// Bucket is the stored state of a key. A zero Bucket is full.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+	// FullAt is when the bucket will have refilled completely, after which it
+	// can be forgotten.
+	FullAt time.Time
+}
+
+// Store keeps buckets. The in-memory store is enough for a single replica;
+// the Postgres store shares buckets across replicas.
+type Store interface {
+	// Update passes the current bucket for key to fn and saves the bucket it
+	// returns. Concurrent updates of the same key must not interleave.
+	Update(ctx context.Context, key string, fn func(Bucket) Bucket) error
+}
+
+// Decision is the outcome of a single request.
+type Decision struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is how many more requests are allowed right now.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long a rejected client has to wait for the next token.
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key if one is available.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Decision, error) {
+	now := l.now()
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+	decision := Decision{Limit: limit}
+
+	err := l.store.Update(ctx, key, func(b Bucket) Bucket {
+		tokens := capacity
+		if !b.UpdatedAt.IsZero() {
+			tokens = min(capacity, b.Tokens+now.Sub(b.UpdatedAt).Seconds()*rate)
+		}
+
+		if tokens >= 1 {
+			tokens--
+			decision.Allowed = true
+		} else {
+			decision.RetryAfter = secondsToDuration((1 - tokens) / rate)
+		}
+		decision.Remaining = int(math.Floor(tokens))
+		decision.Reset = secondsToDuration((capacity - tokens) / rate)
+
+		return Bucket{
+			Tokens:    tokens,
+			UpdatedAt: now,
+			FullAt:    now.Add(decision.Reset),
+		}
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+
+	return decision, nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
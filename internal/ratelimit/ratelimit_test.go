@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limit := Limit{Requests: 3, Per: 3 * time.Second}
+
+	l := NewLimiter(NewMemoryStore())
+	l.now = func() time.Time { return now }
+
+	for i := 1; i <= 3; i++ {
+		decision, err := l.Allow(ctx, "chirps:user:1", limit)
+		if err != nil {
+			t.Fatalf("Allow errored: %v", err)
+		}
+		if !decision.Allowed || decision.Remaining != 3-i {
+			t.Fatalf("request %d: got %+v; want allowed with %d remaining", i, decision, 3-i)
+		}
+	}
+
+	decision, _ := l.Allow(ctx, "chirps:user:1", limit)
+	if decision.Allowed || decision.RetryAfter != time.Second || decision.Reset != 3*time.Second {
+		t.Fatalf("got %+v; want rejected, retry after 1s, reset in 3s", decision)
+	}
+
+	decision, _ = l.Allow(ctx, "chirps:user:2", limit)
+	if !decision.Allowed {
+		t.Errorf("other keys should have their own bucket, got %+v", decision)
+	}
+
+	now = now.Add(time.Second)
+	decision, _ = l.Allow(ctx, "chirps:user:1", limit)
+	if !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("got %+v; want one refilled token", decision)
+	}
+
+	now = now.Add(time.Hour)
+	decision, _ = l.Allow(ctx, "chirps:user:1", limit)
+	if !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("refill must stop at the limit, got %+v", decision)
+	}
+}
+
+func TestPolicyLimitFor(t *testing.T) {
+	free := Limit{Requests: 10, Per: time.Minute}
+	red := Limit{Requests: 30, Per: time.Minute}
+
+	tests := []struct {
+		name      string
+		policy    Policy
+		chirpyRed bool
+		want      Limit
+	}{
+		{
+			name:      "Test 1: Free Account",
+			policy:    Policy{Default: free, ChirpyRed: red},
+			chirpyRed: false,
+			want:      free,
+		},
+		{
+			name:      "Test 2: Chirpy Red Account",
+			policy:    Policy{Default: free, ChirpyRed: red},
+			chirpyRed: true,
+			want:      red,
+		},
+		{
+			name:      "Test 3: Chirpy Red Without Own Limit",
+			policy:    Policy{Default: free},
+			chirpyRed: true,
+			want:      free,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.policy.LimitFor(tt.chirpyRed)
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Limit
+		wantErr bool
+	}{
+		{
+			name:  "Test 1: Requests Per Minute",
+			input: "10/1m",
+			want:  Limit{Requests: 10, Per: time.Minute},
+		},
+		{
+			name:  "Test 2: Spaces Around Parts",
+			input: "5 / 1h",
+			want:  Limit{Requests: 5, Per: time.Hour},
+		},
+		{
+			name:    "Test 3: Missing Duration",
+			input:   "10",
+			wantErr: true,
+		},
+		{
+			name:    "Test 4: Zero Requests",
+			input:   "0/1m",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseLimit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error: %v; want error: %v", err, tt.wantErr)
+			}
+			if actual != tt.want {
+				t.Errorf("got: %v; want: %v", actual, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrRejectedAsSpam is the ValidationError for chirps the spam check rejects.
+var ErrRejectedAsSpam error = ValidationError{msg: "Chirp was rejected as spam"}
+
+// CreatedChirp is a new chirp with the spam check's decision on it. Chirps the
+// check holds are hidden until a moderator reviews them.
+type CreatedChirp struct {
+	database.Chirp
+	Spam spam.Decision
+}
+
+func (c CreatedChirp) Held() bool {
+	return c.Spam.Verdict == spam.VerdictHold
+}
+
+// ChirpList is a list of chirps and when any chirp list last changed.
+type ChirpList struct {
+	Chirps []database.Chirp
+	// ChangedAt is read before the chirps, so it is never newer than them
+	ChangedAt time.Time
+}
+
+// GetChirp returns a chirp if viewer may see it. viewer is nil for anonymous
+// callers. Chirps they may not see are reported as ErrNotFound, so their
+// existence isn't revealed.
+func (s *Service) GetChirp(ctx context.Context, viewer *auth.Principal, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := s.store.GetChirpByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Chirp{}, ErrNotFound
+	}
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	visible, err := chirpVisible(ctx, s.store, chirp, viewer)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !visible {
+		return database.Chirp{}, ErrNotFound
+	}
+	return chirp, nil
+}
+
+// chirpVisible applies the same rules as the chirp list queries to a single
+// chirp. Moderators see everything so they can review it.
+func chirpVisible(ctx context.Context, store storage.Store, chirp database.Chirp, viewer *auth.Principal) (bool, error) {
+	if viewer != nil && viewer.Role.AtLeast(auth.RoleModerator) {
+		return true, nil
+	}
+	isAuthor := viewer != nil && viewer.UserID == chirp.UserID
+
+	if chirp.HiddenAt.Valid && !isAuthor {
+		return false, nil
+	}
+
+	author, err := store.GetUserByID(ctx, chirp.UserID)
+	if err != nil {
+		return false, err
+	}
+	if author.SuspendedUntil.Valid && author.SuspendedUntil.Time.After(time.Now()) {
+		return false, nil
+	}
+	if author.Shadowbanned && !isAuthor {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// ListChirps returns the chirps viewer may see, oldest first, optionally only
+// those by authorID. Shadowbanned authors still see their own chirps.
+func (s *Service) ListChirps(ctx context.Context, viewer *auth.Principal, authorID uuid.NullUUID) (ChirpList, error) {
+	viewerID := uuid.NullUUID{}
+	if viewer != nil {
+		viewerID = uuid.NullUUID{UUID: viewer.UserID, Valid: true}
+	}
+
+	// A change between the two reads then makes the list newer than
+	// ChangedAt, never older, so If-Modified-Since can't hide it
+	changedAt, err := s.store.GetChirpsChangedAt(ctx)
+	if err != nil {
+		return ChirpList{}, err
+	}
+
+	var chirps []database.Chirp
+	if authorID.Valid {
+		chirps, err = s.store.GetAllChirpsByUser(ctx, database.GetAllChirpsByUserParams{
+			UserID:   authorID.UUID,
+			ViewerID: viewerID,
+		})
+	} else {
+		chirps, err = s.store.GetAllChirps(ctx, viewerID)
+	}
+	if err != nil {
+		return ChirpList{}, err
+	}
+	return ChirpList{Chirps: chirps, ChangedAt: changedAt}, nil
+}
+
+// CreateChirp posts body for authorID after the length, profanity and spam
+// checks. Profanity is checked against the lists for locale, the language the
+// chirp is written in, as well as the default list. The chirp, the spam
+// decision and any review it is queued for are written together, so a held or
+// flagged chirp is never visible without its report.
+func (s *Service) CreateChirp(ctx context.Context, authorID uuid.UUID, body, locale string) (CreatedChirp, error) {
+	if len(body) > s.conf.ChirpMaxLength {
+		return CreatedChirp{}, ValidationError{msg: "Chirp is too long"}
+	}
+
+	filtered := s.conf.Profanity.Check(locale, body)
+	if filtered.Action == profanity.ActionReject {
+		return CreatedChirp{}, ValidationError{msg: "Chirp contains blocked words"}
+	}
+
+	created := CreatedChirp{}
+	err := s.store.InTx(ctx, func(tx storage.Store) error {
+		// Stored chirps are masked, so compare the masked text with them
+		decision, err := s.checkSpam(ctx, tx, authorID, filtered.Text)
+		if err != nil {
+			return fmt.Errorf("checking for spam: %w", err)
+		}
+		created = CreatedChirp{Spam: decision}
+		// Rejections are logged for moderators too, but nothing is posted
+		if decision.Verdict == spam.VerdictReject {
+			return recordSpamDecision(ctx, tx, authorID, nil, body, decision)
+		}
+
+		created.Chirp, err = tx.CreateChirp(ctx, database.CreateChirpParams{
+			Body:   filtered.Text,
+			UserID: authorID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordSpamDecision(ctx, tx, authorID, &created.ID, body, decision)
+		if err != nil {
+			return err
+		}
+		if created.Held() {
+			err = holdChirpForReview(ctx, tx, created.ID, decision)
+			if err != nil {
+				return err
+			}
+		}
+
+		if filtered.Action == profanity.ActionFlag {
+			_, err = tx.CreateChirpReport(ctx, database.CreateChirpReportParams{
+				ChirpID: created.ID,
+				Reason:  ReportReasonFilter,
+				Details: "Flagged words: " + strings.Join(filtered.Matches, ", "),
+			})
+			if err != nil {
+				return fmt.Errorf("queueing flagged chirp for review: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return CreatedChirp{}, err
+	}
+	if created.Spam.Verdict == spam.VerdictReject {
+		return created, ErrRejectedAsSpam
+	}
+	return created, nil
+}
+
+// DeleteChirp deletes a chirp for actor and returns it. Moderators may delete
+// anyone's chirp, everyone else only their own. When expect is not nil the
+// chirp is only deleted if expect accepts it as stored, so a client can make
+// sure it deletes the version it last read.
+func (s *Service) DeleteChirp(ctx context.Context, actor auth.Principal, id uuid.UUID, expect func(database.Chirp) bool) (database.Chirp, error) {
+	deleted := database.Chirp{}
+	err := s.store.InTx(ctx, func(tx storage.Store) error {
+		chirp, err := tx.GetChirpByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if chirp.UserID != actor.UserID && !actor.Role.AtLeast(auth.RoleModerator) {
+			return ErrForbidden
+		}
+		if expect != nil && !expect(chirp) {
+			return ErrChanged
+		}
+
+		deleted = chirp
+		return tx.DeleteChirpByID(ctx, id)
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Actions a moderator can take on a reported chirp.
+const (
+	ModerationDismiss = "dismiss"
+	ModerationHide    = "hide"
+	ModerationDelete  = "delete"
+	ModerationSuspend = "suspend"
+)
+
+var moderationActions = []string{ModerationDismiss, ModerationHide, ModerationDelete, ModerationSuspend}
+
+// DefaultSuspension is how long ModerationSuspend suspends an author when the
+// moderator gives no duration.
+const DefaultSuspension = 7 * 24 * time.Hour
+
+// ModerateChirp applies a moderator's action to a reported chirp and records
+// it. The reports, the chirp, the author's suspension and the recorded action
+// are all written in one transaction, so a failure part way leaves nothing
+// half done. suspendFor only applies to ModerationSuspend.
+func (s *Service) ModerateChirp(ctx context.Context, moderator auth.Principal, chirpID uuid.UUID, action, note string, suspendFor time.Duration) (database.ModerationAction, error) {
+	if !slices.Contains(moderationActions, action) {
+		return database.ModerationAction{}, ValidationError{msg: "Invalid action: accepts only dismiss, hide, delete or suspend"}
+	}
+	if suspendFor <= 0 {
+		suspendFor = DefaultSuspension
+	}
+
+	recorded := database.ModerationAction{}
+	err := s.store.InTx(ctx, func(tx storage.Store) error {
+		chirp, err := tx.GetChirpByID(ctx, chirpID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case ModerationDismiss:
+			err = tx.ResolveReportsForChirp(ctx, chirpID)
+			if err == nil && (chirp.HiddenReason.String == HiddenByReports || chirp.HiddenReason.String == HiddenBySpamCheck) {
+				err = tx.UnhideChirp(ctx, chirpID)
+			}
+		case ModerationHide:
+			err = hideChirpByModerator(ctx, tx, chirpID)
+		case ModerationDelete:
+			// Open reports are removed along with the chirp
+			err = tx.DeleteChirpByID(ctx, chirpID)
+		case ModerationSuspend:
+			err = canModerateUser(ctx, tx, moderator, chirp.UserID)
+			if err != nil {
+				return err
+			}
+			err = tx.SuspendUser(ctx, database.SuspendUserParams{
+				ID:             chirp.UserID,
+				SuspendedUntil: sql.NullTime{Time: time.Now().Add(suspendFor), Valid: true},
+			})
+			if err == nil {
+				err = hideChirpByModerator(ctx, tx, chirpID)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		recorded, err = tx.CreateModerationAction(ctx, database.CreateModerationActionParams{
+			ModeratorID: moderator.UserID,
+			ChirpID:     chirpID,
+			AuthorID:    chirp.UserID,
+			Action:      action,
+			Note:        note,
+		})
+		return err
+	})
+	if err != nil {
+		return database.ModerationAction{}, err
+	}
+	return recorded, nil
+}
+
+// hideChirpByModerator hides the chirp for good and closes its open reports.
+func hideChirpByModerator(ctx context.Context, store storage.Store, chirpID uuid.UUID) error {
+	err := store.HideChirp(ctx, database.HideChirpParams{
+		ID:           chirpID,
+		HiddenReason: sql.NullString{String: HiddenByModerator, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	return store.ResolveReportsForChirp(ctx, chirpID)
+}
+
+// CanModerateUser returns ErrForbidden when actor may not suspend or
+// shadowban userID: moderators may not act on their peers or admins.
+func (s *Service) CanModerateUser(ctx context.Context, actor auth.Principal, userID uuid.UUID) error {
+	return canModerateUser(ctx, s.store, actor, userID)
+}
+
+func canModerateUser(ctx context.Context, store storage.Store, actor auth.Principal, userID uuid.UUID) error {
+	target, err := store.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	targetRole, _ := auth.ParseRole(target.Role)
+	if targetRole.AtLeast(auth.RoleModerator) && !actor.Role.AtLeast(auth.RoleAdmin) {
+		return ErrForbidden
+	}
+	return nil
+}
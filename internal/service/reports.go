@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ReportReasons are the reasons users may give when reporting a chirp.
+var ReportReasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"misinformation",
+	"other",
+}
+
+// ReportReasonFilter marks reports the profanity filter files for flagged
+// chirps. Users cannot pick it.
+const ReportReasonFilter = "filter"
+
+// Why a chirp is hidden. Chirps hidden by reports or held by the spam check
+// come back if a moderator dismisses the reports; chirps hidden by a
+// moderator stay hidden.
+const (
+	HiddenByReports   = "reports"
+	HiddenBySpamCheck = "spam"
+	HiddenByModerator = "moderator"
+)
+
+const reportDetailsMaxLength = 500
+
+// ReportChirp files a report by reporterID. Once ReportHideThreshold reports
+// are open the chirp is hidden, in the same transaction, until a moderator
+// reviews it.
+func (s *Service) ReportChirp(ctx context.Context, reporterID, chirpID uuid.UUID, reason, details string) (database.ChirpReport, error) {
+	if !slices.Contains(ReportReasons, reason) {
+		return database.ChirpReport{}, ValidationError{msg: "Invalid reason: accepts only spam, harassment, hate, violence, misinformation or other"}
+	}
+	if len(details) > reportDetailsMaxLength {
+		return database.ChirpReport{}, ValidationError{msg: "Report details are too long"}
+	}
+
+	report := database.ChirpReport{}
+	err := s.store.InTx(ctx, func(tx storage.Store) error {
+		chirp, err := tx.GetChirpByID(ctx, chirpID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if chirp.UserID == reporterID {
+			return ValidationError{msg: "You cannot report your own Chirp"}
+		}
+
+		report, err = tx.CreateChirpReport(ctx, database.CreateChirpReportParams{
+			ChirpID:    chirpID,
+			ReporterID: uuid.NullUUID{UUID: reporterID, Valid: true},
+			Reason:     reason,
+			Details:    details,
+		})
+		// ON CONFLICT DO NOTHING returns no row when this reporter already reported it
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAlreadyReported
+		}
+		if err != nil {
+			return err
+		}
+
+		openReports, err := tx.CountOpenReportsForChirp(ctx, chirpID)
+		if err != nil {
+			return err
+		}
+		if chirp.HiddenAt.Valid || openReports < int64(s.conf.ReportHideThreshold) {
+			return nil
+		}
+		return tx.HideChirp(ctx, database.HideChirpParams{
+			ID:           chirpID,
+			HiddenReason: sql.NullString{String: HiddenByReports, Valid: true},
+		})
+	})
+	if err != nil {
+		return database.ChirpReport{}, err
+	}
+	return report, nil
+}
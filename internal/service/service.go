@@ -0,0 +1,65 @@
+// Package service holds Chirpy's business rules, such as who may delete a
+// chirp, how long one may be and when reports hide it, so every transport
+// applies them the same way. Operations that write more than once run in a
+// single transaction.
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrForbidden       = errors.New("not allowed")
+	ErrAlreadyReported = errors.New("chirp already reported by this user")
+	// ErrChanged rejects a write whose caller expected an older version
+	ErrChanged = errors.New("changed since it was read")
+	// ErrAdminExists stops BootstrapAdmin once anyone is an admin
+	ErrAdminExists = errors.New("an admin already exists")
+)
+
+// ValidationError rejects input the caller can fix. Its message is meant to
+// be shown to them.
+type ValidationError struct {
+	msg string
+}
+
+func (e ValidationError) Error() string {
+	return e.msg
+}
+
+// SuspendedError is returned for actions a suspended account may not take.
+type SuspendedError struct {
+	Until time.Time
+}
+
+func (e SuspendedError) Error() string {
+	return "account suspended until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+type Config struct {
+	ChirpMaxLength int
+	// ReportHideThreshold open reports hide a chirp until it is reviewed
+	ReportHideThreshold int
+	// Profanity may be reloaded while the service uses it
+	Profanity       *profanity.Filter
+	Spam            spam.Config
+	JWTKeys         *auth.KeySet
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+type Service struct {
+	store storage.TxStore
+	conf  Config
+}
+
+func New(store storage.TxStore, conf Config) *Service {
+	return &Service{store: store, conf: conf}
+}
@@ -0,0 +1,578 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func newTestService(t *testing.T) (*Service, *storage.MemoryStore) {
+	t.Helper()
+	store := storage.NewMemoryStore()
+	return New(store, Config{
+		ChirpMaxLength:      140,
+		ReportHideThreshold: 2,
+		Profanity: profanity.NewFilter([]profanity.Word{
+			{Locale: "en", Word: "kerfuffle", Action: profanity.ActionMask},
+			{Locale: "en", Word: "fornax", Action: profanity.ActionFlag},
+			{Locale: "en", Word: "sharbert", Action: profanity.ActionReject},
+			{Locale: "de", Word: "quatsch", Action: profanity.ActionMask},
+		}),
+		Spam:            spam.DefaultConfig,
+		JWTKeys:         auth.NewHMACKeySet("c2VjdXJlLXJhbmRvbS1ieXRlcy1mb3ItdGVzdHMtb25seQ=="),
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: time.Hour,
+	}), store
+}
+
+func mustCreateUser(t *testing.T, store storage.Store, email string) database.User {
+	t.Helper()
+	user, err := store.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          email,
+		HashedPassword: sql.NullString{String: "hash", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestCreateChirp(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		locale      string
+		wantErr     error
+		wantBody    string
+		wantReports int64
+	}{
+		{
+			name:     "Test 1: Allowed",
+			body:     "Just setting up my chirpy",
+			wantBody: "Just setting up my chirpy",
+		},
+		{
+			name:     "Test 2: Masked",
+			body:     "What a kerfuffle",
+			wantBody: "What a ****",
+		},
+		{
+			name:        "Test 3: Flagged For Review",
+			body:        "Fornax is rising",
+			wantBody:    "**** is rising",
+			wantReports: 1,
+		},
+		{
+			name:    "Test 4: Rejected Word",
+			body:    "Hello sharbert",
+			wantErr: ValidationError{msg: "Chirp contains blocked words"},
+		},
+		{
+			name:    "Test 5: Too Long",
+			body:    strings.Repeat("a", 141),
+			wantErr: ValidationError{msg: "Chirp is too long"},
+		},
+		{
+			name:     "Test 6: Other Locale",
+			body:     "Quatsch, kerfuffle",
+			locale:   "de",
+			wantBody: "****, ****",
+		},
+		{
+			name:     "Test 7: Regional Locale",
+			body:     "Quatsch, kerfuffle",
+			locale:   "de-AT",
+			wantBody: "****, ****",
+		},
+		{
+			name:    "Test 8: Rejected Word In Unknown Locale",
+			body:    "Hello sharbert",
+			locale:  "zz",
+			wantErr: ValidationError{msg: "Chirp contains blocked words"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t)
+			author := mustCreateUser(t, store, "author@example.com")
+
+			created, err := svc.CreateChirp(ctx, author.ID, tt.body, tt.locale)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if created.Body != tt.wantBody {
+				t.Errorf("got: %v; want: %v", created.Body, tt.wantBody)
+			}
+
+			reports, err := store.CountOpenReportsForChirp(ctx, created.ID)
+			if err != nil || reports != tt.wantReports {
+				t.Errorf("reports got: %v, %v; want: %v", reports, err, tt.wantReports)
+			}
+			decisions, err := store.ListSpamDecisions(ctx, database.ListSpamDecisionsParams{RowLimit: 10})
+			if err != nil || len(decisions) != 1 {
+				t.Errorf("spam decisions got: %v, %v; want: 1", len(decisions), err)
+			}
+		})
+	}
+}
+
+func TestCreateChirpMaskedDuplicate(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t)
+	author := mustCreateUser(t, store, "author@example.com")
+
+	_, err := svc.CreateChirp(ctx, author.ID, "What a kerfuffle", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := svc.CreateChirp(ctx, author.ID, "What a kerfuffle", "")
+	if !errors.Is(err, ErrRejectedAsSpam) {
+		t.Errorf("got: %v; want: %v", err, ErrRejectedAsSpam)
+	}
+	if created.Spam.Verdict != spam.VerdictReject {
+		t.Errorf("got: %v; want: %v", created.Spam.Verdict, spam.VerdictReject)
+	}
+}
+
+func TestDeleteChirp(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		role    auth.Role
+		missing bool
+		stale   bool
+		wantErr error
+	}{
+		{
+			name:  "Test 1: Author",
+			actor: "author",
+			role:  auth.RoleUser,
+		},
+		{
+			name:    "Test 2: Someone Else",
+			actor:   "other",
+			role:    auth.RoleUser,
+			wantErr: ErrForbidden,
+		},
+		{
+			name:  "Test 3: Moderator",
+			actor: "other",
+			role:  auth.RoleModerator,
+		},
+		{
+			name:    "Test 4: Missing Chirp",
+			actor:   "author",
+			role:    auth.RoleUser,
+			missing: true,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "Test 5: Changed Since Read",
+			actor:   "author",
+			role:    auth.RoleUser,
+			stale:   true,
+			wantErr: ErrChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t)
+			users := map[string]database.User{
+				"author": mustCreateUser(t, store, "author@example.com"),
+				"other":  mustCreateUser(t, store, "other@example.com"),
+			}
+			chirp, err := svc.CreateChirp(ctx, users["author"].ID, "Delete me", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			chirpID := chirp.ID
+			if tt.missing {
+				chirpID = uuid.New()
+			}
+
+			actor := auth.Principal{UserID: users[tt.actor].ID, Role: tt.role}
+			expect := func(c database.Chirp) bool { return !tt.stale }
+			_, err = svc.DeleteChirp(ctx, actor, chirpID, expect)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+
+			_, err = store.GetChirpByID(ctx, chirp.ID)
+			if deleted := errors.Is(err, sql.ErrNoRows); deleted != (tt.wantErr == nil) {
+				t.Errorf("deleted got: %v; want: %v", deleted, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestReportChirp(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t)
+	author := mustCreateUser(t, store, "author@example.com")
+	first := mustCreateUser(t, store, "first@example.com")
+	second := mustCreateUser(t, store, "second@example.com")
+	chirp, err := svc.CreateChirp(ctx, author.ID, "Report me", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cases run in order, so the later ones see the earlier reports
+	tests := []struct {
+		name       string
+		reporterID uuid.UUID
+		reason     string
+		wantErr    error
+		wantHidden bool
+	}{
+		{
+			name:       "Test 1: Own Chirp",
+			reporterID: author.ID,
+			reason:     "spam",
+			wantErr:    ValidationError{msg: "You cannot report your own Chirp"},
+		},
+		{
+			name:       "Test 2: Reserved Reason",
+			reporterID: first.ID,
+			reason:     ReportReasonFilter,
+			wantErr:    ValidationError{msg: "Invalid reason: accepts only spam, harassment, hate, violence, misinformation or other"},
+		},
+		{
+			name:       "Test 3: Below Threshold",
+			reporterID: first.ID,
+			reason:     "spam",
+		},
+		{
+			name:       "Test 4: Reported Twice",
+			reporterID: first.ID,
+			reason:     "hate",
+			wantErr:    ErrAlreadyReported,
+		},
+		{
+			name:       "Test 5: Threshold Hides",
+			reporterID: second.ID,
+			reason:     "hate",
+			wantHidden: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ReportChirp(ctx, tt.reporterID, chirp.ID, tt.reason, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+
+			stored, err := store.GetChirpByID(ctx, chirp.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.HiddenAt.Valid != tt.wantHidden {
+				t.Errorf("hidden got: %v; want: %v", stored.HiddenAt.Valid, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestModerateChirp(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        string
+		authorRole    auth.Role
+		missing       bool
+		wantErr       error
+		wantDeleted   bool
+		wantHidden    bool
+		wantSuspended bool
+	}{
+		{
+			name:       "Test 1: Dismiss",
+			action:     ModerationDismiss,
+			authorRole: auth.RoleUser,
+		},
+		{
+			name:       "Test 2: Hide",
+			action:     ModerationHide,
+			authorRole: auth.RoleUser,
+			wantHidden: true,
+		},
+		{
+			name:        "Test 3: Delete",
+			action:      ModerationDelete,
+			authorRole:  auth.RoleUser,
+			wantDeleted: true,
+		},
+		{
+			name:          "Test 4: Suspend",
+			action:        ModerationSuspend,
+			authorRole:    auth.RoleUser,
+			wantHidden:    true,
+			wantSuspended: true,
+		},
+		{
+			name:       "Test 5: Suspend A Moderator",
+			action:     ModerationSuspend,
+			authorRole: auth.RoleModerator,
+			wantErr:    ErrForbidden,
+		},
+		{
+			name:       "Test 6: Unknown Action",
+			action:     "ban",
+			authorRole: auth.RoleUser,
+			wantErr:    ValidationError{msg: "Invalid action: accepts only dismiss, hide, delete or suspend"},
+		},
+		{
+			name:       "Test 7: Missing Chirp",
+			action:     ModerationHide,
+			authorRole: auth.RoleUser,
+			missing:    true,
+			wantErr:    ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t)
+			author := mustCreateUser(t, store, "author@example.com")
+			moderator := mustCreateUser(t, store, "moderator@example.com")
+			reporter := mustCreateUser(t, store, "reporter@example.com")
+			_, err := store.SetUserRole(ctx, database.SetUserRoleParams{ID: author.ID, Role: string(tt.authorRole)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			chirp, err := svc.CreateChirp(ctx, author.ID, "Moderate me", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = svc.ReportChirp(ctx, reporter.ID, chirp.ID, "spam", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			chirpID := chirp.ID
+			if tt.missing {
+				chirpID = uuid.New()
+			}
+
+			actor := auth.Principal{UserID: moderator.ID, Role: auth.RoleModerator}
+			recorded, err := svc.ModerateChirp(ctx, actor, chirpID, tt.action, "", 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+			if err == nil && (recorded.Action != tt.action || recorded.AuthorID != author.ID) {
+				t.Errorf("got: %+v; want a %s action on %v", recorded, tt.action, author.ID)
+			}
+
+			stored, err := store.GetChirpByID(ctx, chirp.ID)
+			if deleted := errors.Is(err, sql.ErrNoRows); deleted != tt.wantDeleted {
+				t.Fatalf("deleted got: %v (%v); want: %v", deleted, err, tt.wantDeleted)
+			}
+			if !tt.wantDeleted && stored.HiddenAt.Valid != tt.wantHidden {
+				t.Errorf("hidden got: %v; want: %v", stored.HiddenAt.Valid, tt.wantHidden)
+			}
+			// Actions that succeed close the reports; refused ones leave them open
+			reports, err := store.CountOpenReportsForChirp(ctx, chirp.ID)
+			wantReports := int64(0)
+			if tt.wantErr != nil {
+				wantReports = 1
+			}
+			if err != nil || reports != wantReports {
+				t.Errorf("open reports got: %v, %v; want: %v", reports, err, wantReports)
+			}
+			storedAuthor, err := store.GetUserByID(ctx, author.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if storedAuthor.SuspendedUntil.Valid != tt.wantSuspended {
+				t.Errorf("suspended got: %v; want: %v", storedAuthor.SuspendedUntil.Valid, tt.wantSuspended)
+			}
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t)
+
+	user, err := svc.CreateUser(ctx, "new@example.com", "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched, err := auth.CheckPasswordHash("correct horse battery staple", user.HashedPassword.String)
+	if err != nil || !matched {
+		t.Errorf("password hash got: %v, %v; want: true", matched, err)
+	}
+
+	_, err = svc.CreateUser(ctx, "new@example.com", "another password")
+	wantErr := ValidationError{msg: "Email is already in use"}
+	if !errors.Is(err, wantErr) {
+		t.Errorf("got: %v; want: %v", err, wantErr)
+	}
+}
+
+func TestUpgradeToChirpyRed(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t)
+	user := mustCreateUser(t, store, "user@example.com")
+
+	// Cases run in order, so a redelivered upgrade finds the user upgraded
+	tests := []struct {
+		name         string
+		userID       uuid.UUID
+		wantErr      error
+		wantUpgraded bool
+	}{
+		{
+			name:    "Test 1: Unknown User",
+			userID:  uuid.New(),
+			wantErr: ErrNotFound,
+		},
+		{
+			name:         "Test 2: Upgrade",
+			userID:       user.ID,
+			wantUpgraded: true,
+		},
+		{
+			name:   "Test 3: Redelivered",
+			userID: user.ID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upgraded, err := svc.UpgradeToChirpyRed(ctx, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+			if upgraded != tt.wantUpgraded {
+				t.Errorf("upgraded got: %v; want: %v", upgraded, tt.wantUpgraded)
+			}
+		})
+	}
+
+	stored, err := store.GetUserByID(ctx, user.ID)
+	if err != nil || !stored.IsChirpyRed.Bool {
+		t.Errorf("is_chirpy_red got: %v, %v; want: true", stored.IsChirpyRed.Bool, err)
+	}
+}
+
+func TestStartSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		suspended bool
+		wantErr   bool
+	}{
+		{
+			name: "Test 1: Active Account",
+		},
+		{
+			name:      "Test 2: Suspended Account",
+			suspended: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, store := newTestService(t)
+			user := mustCreateUser(t, store, "user@example.com")
+			if tt.suspended {
+				err := store.SuspendUser(ctx, database.SuspendUserParams{
+					ID:             user.ID,
+					SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			session, err := svc.StartSession(ctx, user.ID)
+			if gotErr := errors.As(err, &SuspendedError{}); gotErr != tt.wantErr {
+				t.Fatalf("got: %v; want suspended error: %v", err, tt.wantErr)
+			}
+
+			// A refresh token is only stored when the session starts
+			stored, err := store.GetRefreshTokensByPrefix(ctx, auth.RefreshTokenPrefix(session.RefreshToken))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotStored := len(stored) == 1; gotStored == tt.wantErr {
+				t.Errorf("refresh token stored got: %v; want: %v", gotStored, !tt.wantErr)
+			}
+			if !tt.wantErr {
+				userID, err := auth.ValidateJWT(session.AccessToken, svc.conf.JWTKeys)
+				if err != nil || userID != user.ID {
+					t.Errorf("got: %v, %v; want: %v", userID, err, user.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t)
+	first := mustCreateUser(t, store, "first@example.com")
+	second := mustCreateUser(t, store, "second@example.com")
+
+	// Cases run in order, so the second bootstrap sees the first admin
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{
+			name:    "Test 1: Unknown User",
+			userID:  uuid.New(),
+			wantErr: ErrNotFound,
+		},
+		{
+			name:   "Test 2: First Admin",
+			userID: first.ID,
+		},
+		{
+			name:    "Test 3: Admin Already Exists",
+			userID:  second.ID,
+			wantErr: ErrAdminExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.BootstrapAdmin(ctx, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got: %v; want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			admin, err := store.GetUserByID(ctx, tt.userID)
+			if err != nil || admin.Role != string(auth.RoleAdmin) {
+				t.Errorf("got: %v, %v; want: %v", admin.Role, err, auth.RoleAdmin)
+			}
+		})
+	}
+
+	second, err := store.GetUserByID(ctx, second.ID)
+	if err != nil || second.Role != string(auth.RoleUser) {
+		t.Errorf("got: %v, %v; want: %v", second.Role, err, auth.RoleUser)
+	}
+}
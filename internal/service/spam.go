@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// NOTE: New chirps are compared with at most this many of the author's chirps
+// from the last day
+const (
+	spamDuplicateWindow = 24 * time.Hour
+	spamRecentChirps    = 50
+)
+
+func (s *Service) checkSpam(ctx context.Context, store storage.Store, authorID uuid.UUID, body string) (spam.Decision, error) {
+	now := time.Now()
+
+	author, err := store.GetUserByID(ctx, authorID)
+	if err != nil {
+		return spam.Decision{}, err
+	}
+	recentChirps, err := store.GetRecentChirpsByUser(ctx, database.GetRecentChirpsByUserParams{
+		UserID:   authorID,
+		Since:    sql.NullTime{Time: now.Add(-spamDuplicateWindow), Valid: true},
+		RowLimit: spamRecentChirps,
+	})
+	if err != nil {
+		return spam.Decision{}, err
+	}
+
+	recent := []spam.RecentChirp{}
+	for _, c := range recentChirps {
+		recent = append(recent, spam.RecentChirp{Body: c.Body, CreatedAt: c.CreatedAt.Time})
+	}
+
+	return s.conf.Spam.Check(spam.Input{
+		Body:            body,
+		AuthorCreatedAt: author.CreatedAt.Time,
+		Recent:          recent,
+		Now:             now,
+	}), nil
+}
+
+func holdChirpForReview(ctx context.Context, store storage.Store, chirpID uuid.UUID, decision spam.Decision) error {
+	err := store.HideChirp(ctx, database.HideChirpParams{
+		ID:           chirpID,
+		HiddenReason: sql.NullString{String: HiddenBySpamCheck, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = store.CreateChirpReport(ctx, database.CreateChirpReportParams{
+		ChirpID: chirpID,
+		Reason:  "spam",
+		Details: "Held by spam check: " + strings.Join(signalNames(decision), ", "),
+	})
+	return err
+}
+
+// recordSpamDecision logs a decision for moderators. chirpID is nil for
+// rejected chirps.
+func recordSpamDecision(ctx context.Context, store storage.Store, authorID uuid.UUID, chirpID *uuid.UUID, body string, decision spam.Decision) error {
+	stored := uuid.NullUUID{}
+	if chirpID != nil {
+		stored = uuid.NullUUID{UUID: *chirpID, Valid: true}
+	}
+
+	return store.CreateSpamDecision(ctx, database.CreateSpamDecisionParams{
+		AuthorID: authorID,
+		ChirpID:  stored,
+		Body:     body,
+		Verdict:  string(decision.Verdict),
+		Score:    int32(decision.Score),
+		Signals:  signalNames(decision),
+	})
+}
+
+func signalNames(decision spam.Decision) []string {
+	signals := []string{}
+	for _, s := range decision.Signals {
+		signals = append(signals, string(s))
+	}
+	return signals
+}
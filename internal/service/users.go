@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// CreateUser signs up a new account. The email check and the insert share a
+// transaction, so two signups with the same email can't both pass the check.
+func (s *Service) CreateUser(ctx context.Context, email, password string) (database.User, error) {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	user := database.User{}
+	err = s.store.InTx(ctx, func(tx storage.Store) error {
+		_, err := tx.GetUserByUsername(ctx, email)
+		if err == nil {
+			return ValidationError{msg: "Email is already in use"}
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		user, err = tx.CreateUser(ctx, database.CreateUserParams{
+			Email:          email,
+			HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+// ProfileUpdate is the result of UpdateUser.
+type ProfileUpdate struct {
+	Before          database.User
+	After           database.User
+	PasswordChanged bool
+}
+
+// UpdateUser replaces a user's email and password. Before is read in the same
+// transaction as the write, so the two describe exactly what changed.
+func (s *Service) UpdateUser(ctx context.Context, userID uuid.UUID, email, password string) (ProfileUpdate, error) {
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		return ProfileUpdate{}, err
+	}
+
+	update := ProfileUpdate{}
+	err = s.store.InTx(ctx, func(tx storage.Store) error {
+		var err error
+		update.Before, err = tx.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		update.After, err = tx.UpdateUser(ctx, database.UpdateUserParams{
+			ID:             userID,
+			Email:          email,
+			HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return ProfileUpdate{}, err
+	}
+
+	// Every new hash differs from the stored one, so compare the password
+	unchanged, _ := auth.CheckPasswordHash(password, update.Before.HashedPassword.String)
+	update.PasswordChanged = !unchanged
+	return update, nil
+}
+
+// Session is what a successful login hands back to the user.
+type Session struct {
+	User         database.User
+	AccessToken  string
+	RefreshToken string
+}
+
+// StartSession issues tokens for a user whose password has been checked. The
+// suspension check and the refresh token share a transaction, and the access
+// token is only signed once the refresh token is stored.
+func (s *Service) StartSession(ctx context.Context, userID uuid.UUID) (Session, error) {
+	refreshToken, prefix, hash, err := auth.MakeRefreshToken()
+	if err != nil {
+		return Session{}, err
+	}
+
+	session := Session{RefreshToken: refreshToken}
+	err = s.store.InTx(ctx, func(tx storage.Store) error {
+		var err error
+		session.User, err = tx.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if session.User.SuspendedUntil.Valid && session.User.SuspendedUntil.Time.After(time.Now()) {
+			return SuspendedError{Until: session.User.SuspendedUntil.Time}
+		}
+
+		// Only the hash is stored, so the raw token is returned from memory
+		_, err = tx.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			TokenPrefix: prefix,
+			TokenHash:   hash,
+			ExpiresAt:   sql.NullTime{Time: time.Now().Add(s.conf.RefreshTokenTTL), Valid: true},
+			UserID:      userID,
+		})
+		return err
+	})
+	if err != nil {
+		return Session{}, err
+	}
+
+	session.AccessToken, err = auth.MakeJWT(userID, s.conf.JWTKeys, s.conf.AccessTokenTTL)
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// BootstrapAdmin makes userID the first admin and returns the role it had
+// before. The check for an existing admin and the promotion share a
+// transaction, and Postgres transactions are serializable, so concurrent
+// bootstraps promote at most one user.
+func (s *Service) BootstrapAdmin(ctx context.Context, userID uuid.UUID) (previousRole string, err error) {
+	err = s.store.InTx(ctx, func(tx storage.Store) error {
+		user, err := tx.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		previousRole = user.Role
+
+		promoted, err := tx.PromoteFirstAdmin(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if promoted == 0 {
+			return ErrAdminExists
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return previousRole, nil
+}
+
+// UpgradeToChirpyRed makes userID a Chirpy Red member and reports whether it
+// wasn't one already, so repeated webhook deliveries are only acted on once.
+func (s *Service) UpgradeToChirpyRed(ctx context.Context, userID uuid.UUID) (upgraded bool, err error) {
+	err = s.store.InTx(ctx, func(tx storage.Store) error {
+		user, err := tx.GetUserByID(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if user.IsChirpyRed.Bool {
+			return nil
+		}
+
+		_, err = tx.UpgradeToChirpyRed(ctx, userID)
+		upgraded = err == nil
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return upgraded, nil
+}
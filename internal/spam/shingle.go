@@ -0,0 +1,51 @@
+package spam
+
+import (
+	"strings"
+	"unicode"
+)
+
+// shingleSize is how many characters make up one shingle. Chirps are short,
+// so character shingles give far more overlap to compare than word shingles.
+const shingleSize = 4
+
+// normalize lower-cases text and drops punctuation and extra spacing so
+// "Buy now!!" and "buy now" compare equal.
+func normalize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// Shingles returns the set of overlapping character shingles of the
+// normalized text.
+func Shingles(text string) map[string]struct{} {
+	runes := []rune(normalize(text))
+	set := map[string]struct{}{}
+	if len(runes) <= shingleSize {
+		set[string(runes)] = struct{}{}
+		return set
+	}
+
+	for i := 0; i+shingleSize <= len(runes); i++ {
+		set[string(runes[i:i+shingleSize])] = struct{}{}
+	}
+	return set
+}
+
+// Similarity is the Jaccard similarity of two shingle sets: 1 for the same
+// text, 0 for texts without a shingle in common.
+func Similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for shingle := range a {
+		if _, ok := b[shingle]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
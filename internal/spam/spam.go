@@ -0,0 +1,153 @@
+// Package spam scores new chirps against the author's recent chirps, using
+// shingle similarity to catch near duplicates and a few heuristics, and
+// decides whether to allow, hold or reject them.
+package spam
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Verdict string
+
+const (
+	VerdictAllow Verdict = "allow"
+	// VerdictHold posts the chirp hidden until a moderator reviews it.
+	VerdictHold   Verdict = "hold"
+	VerdictReject Verdict = "reject"
+)
+
+// Signal is one reason a chirp looks like spam.
+type Signal string
+
+const (
+	SignalExactDuplicate  Signal = "exact_duplicate"
+	SignalNearDuplicate   Signal = "near_duplicate"
+	SignalLinkHeavy       Signal = "link_heavy"
+	SignalNewAccountBurst Signal = "new_account_burst"
+)
+
+// signalScores add up to the score compared with the thresholds. An exact
+// duplicate is rejected on its own by default, the other signals hold.
+var signalScores = map[Signal]int{
+	SignalExactDuplicate:  100,
+	SignalNearDuplicate:   60,
+	SignalLinkHeavy:       40,
+	SignalNewAccountBurst: 40,
+}
+
+type Config struct {
+	// HoldScore and RejectScore are the scores at which chirps are held for
+	// review or rejected.
+	HoldScore   int
+	RejectScore int
+	// NearDuplicateSimilarity is the shingle similarity from which a chirp
+	// counts as a near duplicate of an earlier one.
+	NearDuplicateSimilarity float64
+	// MaxLinks links are allowed before a chirp counts as link heavy. Chirps
+	// with several links and little else count as link heavy too.
+	MaxLinks int
+	// Accounts younger than NewAccountAge may post BurstSize chirps within
+	// BurstWindow before further chirps count as a burst.
+	NewAccountAge time.Duration
+	BurstWindow   time.Duration
+	BurstSize     int
+}
+
+var DefaultConfig = Config{
+	HoldScore:               40,
+	RejectScore:             100,
+	NearDuplicateSimilarity: 0.7,
+	MaxLinks:                2,
+	NewAccountAge:           24 * time.Hour,
+	BurstWindow:             10 * time.Minute,
+	BurstSize:               5,
+}
+
+// RecentChirp is one of the author's earlier chirps.
+type RecentChirp struct {
+	Body      string
+	CreatedAt time.Time
+}
+
+type Input struct {
+	Body            string
+	AuthorCreatedAt time.Time
+	// Recent holds the author's chirps from the duplicate window.
+	Recent []RecentChirp
+	Now    time.Time
+}
+
+type Decision struct {
+	Verdict Verdict
+	Score   int
+	Signals []Signal
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+func (c Config) Check(in Input) Decision {
+	signals := []Signal{}
+
+	normalized := normalize(in.Body)
+	shingles := Shingles(in.Body)
+	duplicate := Signal("")
+	for _, recent := range in.Recent {
+		if normalize(recent.Body) == normalized {
+			duplicate = SignalExactDuplicate
+			break
+		}
+		if Similarity(shingles, Shingles(recent.Body)) >= c.NearDuplicateSimilarity {
+			duplicate = SignalNearDuplicate
+		}
+	}
+	if duplicate != "" {
+		signals = append(signals, duplicate)
+	}
+
+	if isLinkHeavy(in.Body, c.MaxLinks) {
+		signals = append(signals, SignalLinkHeavy)
+	}
+
+	if in.Now.Sub(in.AuthorCreatedAt) < c.NewAccountAge {
+		inWindow := 0
+		for _, recent := range in.Recent {
+			if in.Now.Sub(recent.CreatedAt) < c.BurstWindow {
+				inWindow++
+			}
+		}
+		if inWindow >= c.BurstSize {
+			signals = append(signals, SignalNewAccountBurst)
+		}
+	}
+
+	decision := Decision{Verdict: VerdictAllow, Signals: signals}
+	for _, signal := range signals {
+		decision.Score += signalScores[signal]
+	}
+	switch {
+	case decision.Score >= c.RejectScore:
+		decision.Verdict = VerdictReject
+	case decision.Score >= c.HoldScore:
+		decision.Verdict = VerdictHold
+	}
+
+	return decision
+}
+
+func isLinkHeavy(body string, maxLinks int) bool {
+	links := linkPattern.FindAllString(body, -1)
+	if len(links) < 2 {
+		return len(links) > maxLinks
+	}
+	if len(links) > maxLinks {
+		return true
+	}
+
+	linkLength := 0
+	for _, link := range links {
+		linkLength += len(link)
+	}
+	return linkLength*2 > len(strings.TrimSpace(body))
+}
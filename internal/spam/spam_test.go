@@ -0,0 +1,133 @@
+package spam
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSimilarity(t *testing.T) {
+	base := "Win a free phone today, just click the link in my profile and claim it"
+
+	tests := []struct {
+		name  string
+		other string
+		min   float64
+		max   float64
+	}{
+		{
+			name:  "Test 1: Same Words Different Case And Punctuation",
+			other: "WIN a free phone today!!! Just click the link in my profile, and claim it",
+			min:   1,
+			max:   1,
+		},
+		{
+			name:  "Test 2: One Word Changed",
+			other: "Win a free phone today, just click the link in my bio and claim it",
+			min:   0.7,
+			max:   0.99,
+		},
+		{
+			name:  "Test 3: Unrelated Text",
+			other: "Had a lovely walk by the river this morning with the dog",
+			min:   0,
+			max:   0.2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Similarity(Shingles(base), Shingles(tt.other))
+			if actual < tt.min || actual > tt.max {
+				t.Errorf("got: %v; want between %v and %v", actual, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	oldAccount := now.Add(-30 * 24 * time.Hour)
+	newAccount := now.Add(-time.Hour)
+
+	burst := []RecentChirp{}
+	for i := range 5 {
+		burst = append(burst, RecentChirp{Body: "hello number " + string(rune('a'+i)), CreatedAt: now.Add(-time.Minute)})
+	}
+
+	tests := []struct {
+		name        string
+		input       Input
+		wantVerdict Verdict
+		wantSignals []Signal
+	}{
+		{
+			name:        "Test 1: Ordinary Chirp",
+			input:       Input{Body: "Good morning everyone", AuthorCreatedAt: oldAccount, Now: now},
+			wantVerdict: VerdictAllow,
+			wantSignals: []Signal{},
+		},
+		{
+			name: "Test 2: Exact Duplicate",
+			input: Input{
+				Body:            "Buy followers now",
+				AuthorCreatedAt: oldAccount,
+				Recent:          []RecentChirp{{Body: "buy followers NOW!", CreatedAt: now.Add(-time.Hour)}},
+				Now:             now,
+			},
+			wantVerdict: VerdictReject,
+			wantSignals: []Signal{SignalExactDuplicate},
+		},
+		{
+			name: "Test 3: Near Duplicate",
+			input: Input{
+				Body:            "Win a free phone today, just click the link in my bio and claim it",
+				AuthorCreatedAt: oldAccount,
+				Recent:          []RecentChirp{{Body: "Win a free phone today, just click the link in my profile and claim it", CreatedAt: now.Add(-time.Hour)}},
+				Now:             now,
+			},
+			wantVerdict: VerdictHold,
+			wantSignals: []Signal{SignalNearDuplicate},
+		},
+		{
+			name: "Test 4: Link Heavy",
+			input: Input{
+				Body:            "https://a.example https://b.example https://c.example",
+				AuthorCreatedAt: oldAccount,
+				Now:             now,
+			},
+			wantVerdict: VerdictHold,
+			wantSignals: []Signal{SignalLinkHeavy},
+		},
+		{
+			name:        "Test 5: Single Link With Text",
+			input:       Input{Body: "My new blog post https://blog.example/post", AuthorCreatedAt: oldAccount, Now: now},
+			wantVerdict: VerdictAllow,
+			wantSignals: []Signal{},
+		},
+		{
+			name:        "Test 6: Burst From New Account",
+			input:       Input{Body: "one more", AuthorCreatedAt: newAccount, Recent: burst, Now: now},
+			wantVerdict: VerdictHold,
+			wantSignals: []Signal{SignalNewAccountBurst},
+		},
+		{
+			name:        "Test 7: Burst From Old Account",
+			input:       Input{Body: "one more", AuthorCreatedAt: oldAccount, Recent: burst, Now: now},
+			wantVerdict: VerdictAllow,
+			wantSignals: []Signal{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := DefaultConfig.Check(tt.input)
+			if actual.Verdict != tt.wantVerdict {
+				t.Errorf("got: %v; want: %v", actual.Verdict, tt.wantVerdict)
+			}
+			if !slices.Equal(actual.Signals, tt.wantSignals) {
+				t.Errorf("got: %v; want: %v", actual.Signals, tt.wantSignals)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/cache"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// Results passed to CachedStore's observer.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// CachedStore serves GetChirpByID and GetUserByID from a cache in front of
+// another store. Writes through it delete the rows they change from the
+// cache; writes inside a transaction do so once it commits. A lookup that
+// races a write can still put the old row back, so entries expire after ttl
+// to bound how long that lasts.
+//
+// Concurrent misses for the same row share one query. Cache failures are
+// logged and the lookup falls through to the store.
+type CachedStore struct {
+	invalidatingStore
+	store   TxStore
+	cache   cache.Cache
+	ttl     time.Duration
+	flight  singleflight.Group
+	observe func(table, result string)
+}
+
+// NewCachedStore wraps store. observe, which may be nil, is told the result
+// of every cached lookup.
+func NewCachedStore(store TxStore, c cache.Cache, ttl time.Duration, observe func(table, result string)) *CachedStore {
+	if observe == nil {
+		observe = func(table, result string) {}
+	}
+	s := &CachedStore{store: store, cache: c, ttl: ttl, observe: observe}
+	s.invalidatingStore = invalidatingStore{Store: store, invalidator: s}
+	return s
+}
+
+func chirpKey(id uuid.UUID) string { return "chirp:" + id.String() }
+func userKey(id uuid.UUID) string  { return "user:" + id.String() }
+
+func (s *CachedStore) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	return cachedLookup(ctx, s, "chirp", chirpKey(id), func(ctx context.Context) (database.Chirp, error) {
+		return s.store.GetChirpByID(ctx, id)
+	})
+}
+
+// GetUserByID returns the user without its password hash, which is never
+// cached. Read users from the store, or inside InTx, to check a password.
+func (s *CachedStore) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	cached, err := cachedLookup(ctx, s, "user", userKey(id), func(ctx context.Context) (cachedUser, error) {
+		user, err := s.store.GetUserByID(ctx, id)
+		return newCachedUser(user), err
+	})
+	return cached.user(), err
+}
+
+// cachedUser is the part of a user row that is cached: everything but the
+// password hash, so the hash never reaches a cache server.
+type cachedUser struct {
+	ID             uuid.UUID
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+	Email          string
+	IsChirpyRed    sql.NullBool
+	Role           string
+	SuspendedUntil sql.NullTime
+	Shadowbanned   bool
+}
+
+func newCachedUser(u database.User) cachedUser {
+	return cachedUser{
+		ID:             u.ID,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		Email:          u.Email,
+		IsChirpyRed:    u.IsChirpyRed,
+		Role:           u.Role,
+		SuspendedUntil: u.SuspendedUntil,
+		Shadowbanned:   u.Shadowbanned,
+	}
+}
+
+func (u cachedUser) user() database.User {
+	return database.User{
+		ID:             u.ID,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		Email:          u.Email,
+		IsChirpyRed:    u.IsChirpyRed,
+		Role:           u.Role,
+		SuspendedUntil: u.SuspendedUntil,
+		Shadowbanned:   u.Shadowbanned,
+	}
+}
+
+// InTx reads through to the store inside the transaction, since the cache
+// can't see its uncommitted writes, and invalidates what it changed after
+// it commits.
+func (s *CachedStore) InTx(ctx context.Context, fn func(Store) error) error {
+	pending := &pendingInvalidations{}
+	err := s.store.InTx(ctx, func(tx Store) error {
+		// A retried transaction starts over
+		*pending = pendingInvalidations{}
+		return fn(invalidatingStore{Store: tx, invalidator: pending})
+	})
+	if err != nil {
+		return err
+	}
+
+	if pending.all {
+		s.invalidateAll(ctx)
+	} else if len(pending.keys) > 0 {
+		s.invalidate(ctx, pending.keys...)
+	}
+	return nil
+}
+
+// cachedLookup returns the row under key, loading and caching it on a miss.
+// Missing rows are not cached.
+func cachedLookup[T any](ctx context.Context, s *CachedStore, table, key string, load func(context.Context) (T, error)) (T, error) {
+	var row T
+	data, found, err := s.cache.Get(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Cache read failed", "key", key, "error", err)
+		s.observe(table, CacheError)
+	} else if found && json.Unmarshal(data, &row) == nil {
+		s.observe(table, CacheHit)
+		return row, nil
+	} else {
+		s.observe(table, CacheMiss)
+	}
+
+	// The shared query outlives a caller that gives up, so the others
+	// waiting on it still get their answer
+	shared := context.WithoutCancel(ctx)
+	result := s.flight.DoChan(key, func() (any, error) {
+		row, err := load(shared)
+		if err != nil {
+			return row, err
+		}
+		data, err := json.Marshal(row)
+		if err == nil {
+			err = s.cache.Set(shared, key, data, s.ttl)
+		}
+		if err != nil {
+			logging.FromContext(shared).Warn("Cache write failed", "key", key, "error", err)
+		}
+		return row, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return row, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return row, r.Err
+		}
+		return r.Val.(T), nil
+	}
+}
+
+func (s *CachedStore) invalidate(ctx context.Context, keys ...string) {
+	// Lookups after the write must not join a query that started before it
+	for _, key := range keys {
+		s.flight.Forget(key)
+	}
+	err := s.cache.Delete(ctx, keys...)
+	if err != nil {
+		logging.FromContext(ctx).Error("Cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+func (s *CachedStore) invalidateAll(ctx context.Context) {
+	err := s.cache.Clear(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Cache clear failed", "error", err)
+	}
+}
+
+// invalidator is told which cached rows a write changed.
+type invalidator interface {
+	invalidate(ctx context.Context, keys ...string)
+	invalidateAll(ctx context.Context)
+}
+
+// pendingInvalidations collects a transaction's invalidations until it
+// commits.
+type pendingInvalidations struct {
+	keys []string
+	all  bool
+}
+
+func (p *pendingInvalidations) invalidate(ctx context.Context, keys ...string) {
+	p.keys = append(p.keys, keys...)
+}
+
+func (p *pendingInvalidations) invalidateAll(ctx context.Context) {
+	p.all = true
+}
+
+// invalidatingStore reports the rows changed by every write to a cached
+// table. Rows are invalidated even when the write fails, since it may have
+// been applied anyway.
+type invalidatingStore struct {
+	Store
+	invalidator
+}
+
+func (s invalidatingStore) DeleteAllUsers(ctx context.Context) error {
+	// Chirps go with their authors
+	defer s.invalidateAll(ctx)
+	return s.Store.DeleteAllUsers(ctx)
+}
+
+func (s invalidatingStore) PromoteFirstAdmin(ctx context.Context, id uuid.UUID) (int64, error) {
+	defer s.invalidate(ctx, userKey(id))
+	return s.Store.PromoteFirstAdmin(ctx, id)
+}
+
+func (s invalidatingStore) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	defer s.invalidate(ctx, userKey(arg.ID))
+	return s.Store.SetUserRole(ctx, arg)
+}
+
+func (s invalidatingStore) SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) error {
+	defer s.invalidate(ctx, userKey(arg.ID))
+	return s.Store.SetUserShadowbanned(ctx, arg)
+}
+
+func (s invalidatingStore) SuspendUser(ctx context.Context, arg database.SuspendUserParams) error {
+	defer s.invalidate(ctx, userKey(arg.ID))
+	return s.Store.SuspendUser(ctx, arg)
+}
+
+func (s invalidatingStore) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	defer s.invalidate(ctx, userKey(arg.ID))
+	return s.Store.UpdateUser(ctx, arg)
+}
+
+func (s invalidatingStore) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer s.invalidate(ctx, userKey(id))
+	return s.Store.UpgradeToChirpyRed(ctx, id)
+}
+
+func (s invalidatingStore) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	defer s.invalidate(ctx, chirpKey(id))
+	return s.Store.DeleteChirpByID(ctx, id)
+}
+
+func (s invalidatingStore) HideChirp(ctx context.Context, arg database.HideChirpParams) error {
+	defer s.invalidate(ctx, chirpKey(arg.ID))
+	return s.Store.HideChirp(ctx, arg)
+}
+
+func (s invalidatingStore) UnhideChirp(ctx context.Context, id uuid.UUID) error {
+	defer s.invalidate(ctx, chirpKey(id))
+	return s.Store.UnhideChirp(ctx, id)
+}
@@ -0,0 +1,762 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Stand-ins for the constraint violations Postgres reports.
+var (
+	errDuplicateEmail = errors.New("storage: a user with that email already exists")
+	errUnknownUser    = errors.New("storage: user does not exist")
+	errUnknownChirp   = errors.New("storage: chirp does not exist")
+)
+
+// MemoryStore keeps everything in process memory. It is meant for tests and
+// local experiments: nothing survives a restart.
+type MemoryStore struct {
+	mu *sync.Mutex
+	*memoryTables
+}
+
+type memoryTables struct {
+	users map[uuid.UUID]database.User
+	// Rows are kept in insertion order, which is also created_at order
+	chirps            []database.Chirp
+	refreshTokens     []database.RefreshToken
+	pats              []database.PersonalAccessToken
+	reports           []database.ChirpReport
+	moderationActions []database.ModerationAction
+	spamDecisions     []database.SpamDecision
+	profanityWords    map[[2]string]database.ProfanityWord
+	auditEvents       []database.AuditEvent
+	// chirpsChangedAt stands in for the chirp_changes row the Postgres
+	// triggers keep current
+	chirpsChangedAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu: &sync.Mutex{},
+		memoryTables: &memoryTables{
+			users:           map[uuid.UUID]database.User{},
+			profanityWords:  map[[2]string]database.ProfanityWord{},
+			chirpsChangedAt: time.Now(),
+		},
+	}
+}
+
+// clone copies the tables so a transaction can change them without touching
+// the originals. Rows are values whose nested slices are never modified in
+// place, so copying the containers is enough.
+func (t *memoryTables) clone() *memoryTables {
+	return &memoryTables{
+		users:             maps.Clone(t.users),
+		chirps:            slices.Clone(t.chirps),
+		refreshTokens:     slices.Clone(t.refreshTokens),
+		pats:              slices.Clone(t.pats),
+		reports:           slices.Clone(t.reports),
+		moderationActions: slices.Clone(t.moderationActions),
+		spamDecisions:     slices.Clone(t.spamDecisions),
+		profanityWords:    maps.Clone(t.profanityWords),
+		auditEvents:       slices.Clone(t.auditEvents),
+		chirpsChangedAt:   t.chirpsChangedAt,
+	}
+}
+
+// InTx holds the store's lock while fn runs, so transactions are serialized
+// with each other and with single statements. fn works on a copy of the
+// tables, which replaces the originals only if it succeeds.
+func (s *MemoryStore) InTx(ctx context.Context, fn func(Store) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &MemoryStore{mu: &sync.Mutex{}, memoryTables: s.memoryTables.clone()}
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+	s.memoryTables = tx.memoryTables
+	return nil
+}
+
+func now() sql.NullTime {
+	return sql.NullTime{Time: time.Now(), Valid: true}
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.userByEmail(arg.Email); ok {
+		return database.User{}, errDuplicateEmail
+	}
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      now(),
+		UpdatedAt:      now(),
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		IsChirpyRed:    sql.NullBool{Bool: false, Valid: true},
+		Role:           "user",
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+// DeleteAllUsers also removes everything that cascades from users. The
+// moderation, spam and audit history is kept, as in Postgres.
+func (s *MemoryStore) DeleteAllUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = map[uuid.UUID]database.User{}
+	s.chirps = nil
+	s.refreshTokens = nil
+	s.pats = nil
+	s.reports = nil
+	s.chirpsChangedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryStore) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) GetUserByUsername(ctx context.Context, email string) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.userByEmail(email)
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) userByEmail(email string) (database.User, bool) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return database.User{}, false
+}
+
+func (s *MemoryStore) PromoteFirstAdmin(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Role == "admin" {
+			return 0, nil
+		}
+	}
+	user, ok := s.users[id]
+	if !ok {
+		return 0, nil
+	}
+	user.Role = "admin"
+	user.UpdatedAt = now()
+	s.users[user.ID] = user
+	return 1, nil
+}
+
+// updateUser applies fn to the user and returns the result, or sql.ErrNoRows
+// like an UPDATE ... RETURNING that matched nothing.
+func (s *MemoryStore) updateUser(id uuid.UUID, fn func(*database.User)) (database.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	fn(&user)
+	s.users[id] = user
+	return user, nil
+}
+
+func (s *MemoryStore) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.Role = arg.Role
+		u.UpdatedAt = now()
+	})
+}
+
+func (s *MemoryStore) SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.updateUser(arg.ID, func(u *database.User) {
+		u.Shadowbanned = arg.Shadowbanned
+		u.UpdatedAt = now()
+		s.chirpsChangedAt = time.Now()
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (s *MemoryStore) SuspendUser(ctx context.Context, arg database.SuspendUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.updateUser(arg.ID, func(u *database.User) {
+		u.SuspendedUntil = arg.SuspendedUntil
+		u.UpdatedAt = now()
+		s.chirpsChangedAt = time.Now()
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func (s *MemoryStore) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if other, ok := s.userByEmail(arg.Email); ok && other.ID != arg.ID {
+		return database.User{}, errDuplicateEmail
+	}
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+		u.UpdatedAt = now()
+	})
+}
+
+func (s *MemoryStore) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateUser(id, func(u *database.User) {
+		u.IsChirpyRed = sql.NullBool{Bool: true, Valid: true}
+	})
+}
+
+func (s *MemoryStore) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, errUnknownUser
+	}
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: now(),
+		UpdatedAt: now(),
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	s.chirps = append(s.chirps, chirp)
+	s.chirpsChangedAt = time.Now()
+	return chirp, nil
+}
+
+// DeleteChirpByID also removes the chirp's reports.
+func (s *MemoryStore) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chirps = slices.DeleteFunc(s.chirps, func(c database.Chirp) bool { return c.ID == id })
+	s.reports = slices.DeleteFunc(s.reports, func(r database.ChirpReport) bool { return r.ChirpID == id })
+	s.chirpsChangedAt = time.Now()
+	return nil
+}
+
+// visible applies the rules of the chirp list queries.
+func (s *MemoryStore) visible(chirp database.Chirp, viewerID uuid.NullUUID) bool {
+	if chirp.HiddenAt.Valid {
+		return false
+	}
+	author := s.users[chirp.UserID]
+	if author.SuspendedUntil.Valid && author.SuspendedUntil.Time.After(time.Now()) {
+		return false
+	}
+	return !author.Shadowbanned || (viewerID.Valid && viewerID.UUID == author.ID)
+}
+
+func (s *MemoryStore) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Chirp
+	for _, chirp := range s.chirps {
+		if s.visible(chirp, viewerID) {
+			items = append(items, chirp)
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) GetAllChirpsByUser(ctx context.Context, arg database.GetAllChirpsByUserParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Chirp
+	for _, chirp := range s.chirps {
+		if chirp.UserID == arg.UserID && s.visible(chirp, arg.ViewerID) {
+			items = append(items, chirp)
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.chirpIndex(id)
+	if i < 0 {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return s.chirps[i], nil
+}
+
+// GetChirpsChangedAt also counts the end of a suspension that has run out,
+// which changes the lists without any write.
+func (s *MemoryStore) GetChirpsChangedAt(ctx context.Context) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changedAt := s.chirpsChangedAt
+	for _, user := range s.users {
+		if until := user.SuspendedUntil; until.Valid && !until.Time.After(time.Now()) && until.Time.After(changedAt) {
+			changedAt = until.Time
+		}
+	}
+	return changedAt, nil
+}
+
+func (s *MemoryStore) chirpIndex(id uuid.UUID) int {
+	return slices.IndexFunc(s.chirps, func(c database.Chirp) bool { return c.ID == id })
+}
+
+func (s *MemoryStore) GetRecentChirpsByUser(ctx context.Context, arg database.GetRecentChirpsByUserParams) ([]database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.Chirp
+	for i := len(s.chirps) - 1; i >= 0 && len(items) < int(arg.RowLimit); i-- {
+		chirp := s.chirps[i]
+		if chirp.UserID == arg.UserID && !chirp.CreatedAt.Time.Before(arg.Since.Time) {
+			items = append(items, chirp)
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) HideChirp(ctx context.Context, arg database.HideChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.chirpIndex(arg.ID); i >= 0 {
+		if !s.chirps[i].HiddenAt.Valid {
+			s.chirps[i].HiddenAt = now()
+		}
+		s.chirps[i].HiddenReason = arg.HiddenReason
+		s.chirpsChangedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *MemoryStore) UnhideChirp(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.chirpIndex(id); i >= 0 {
+		s.chirps[i].HiddenAt = sql.NullTime{}
+		s.chirps[i].HiddenReason = sql.NullString{}
+		s.chirpsChangedAt = time.Now()
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, errUnknownUser
+	}
+	token := database.RefreshToken{
+		CreatedAt:   now(),
+		UpdatedAt:   now(),
+		ExpiresAt:   arg.ExpiresAt,
+		UserID:      arg.UserID,
+		ID:          uuid.New(),
+		TokenPrefix: arg.TokenPrefix,
+		TokenHash:   arg.TokenHash,
+	}
+	s.refreshTokens = append(s.refreshTokens, token)
+	return token, nil
+}
+
+func (s *MemoryStore) GetRefreshTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.RefreshToken
+	for _, token := range s.refreshTokens {
+		if token.TokenPrefix == tokenPrefix {
+			items = append(items, token)
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.refreshTokens {
+		if s.refreshTokens[i].ID == arg.ID {
+			s.refreshTokens[i].RevokedAt = arg.RevokedAt
+			s.refreshTokens[i].UpdatedAt = arg.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.PersonalAccessToken{}, errUnknownUser
+	}
+	pat := database.PersonalAccessToken{
+		ID:          uuid.New(),
+		CreatedAt:   now(),
+		UpdatedAt:   now(),
+		Name:        arg.Name,
+		TokenPrefix: arg.TokenPrefix,
+		TokenHash:   arg.TokenHash,
+		Scopes:      slices.Clone(arg.Scopes),
+		ExpiresAt:   arg.ExpiresAt,
+		UserID:      arg.UserID,
+	}
+	s.pats = append(s.pats, pat)
+	return clonePAT(pat), nil
+}
+
+// clonePAT copies the scopes so callers cannot modify the stored token.
+func clonePAT(pat database.PersonalAccessToken) database.PersonalAccessToken {
+	pat.Scopes = slices.Clone(pat.Scopes)
+	return pat
+}
+
+func (s *MemoryStore) GetPersonalAccessTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.PersonalAccessToken
+	for _, pat := range s.pats {
+		if pat.TokenPrefix == tokenPrefix {
+			items = append(items, clonePAT(pat))
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) GetPersonalAccessTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.PersonalAccessToken
+	for _, pat := range s.pats {
+		if pat.UserID == userID && !pat.RevokedAt.Valid {
+			items = append(items, clonePAT(pat))
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) RevokePersonalAccessToken(ctx context.Context, arg database.RevokePersonalAccessTokenParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var revoked int64
+	for i := range s.pats {
+		pat := &s.pats[i]
+		if pat.ID == arg.ID && pat.UserID == arg.UserID && !pat.RevokedAt.Valid {
+			pat.RevokedAt = now()
+			pat.UpdatedAt = now()
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *MemoryStore) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.pats {
+		if s.pats[i].ID == id {
+			s.pats[i].LastUsedAt = now()
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CountOpenReportsForChirp(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int64
+	for _, report := range s.reports {
+		if report.ChirpID == chirpID && !report.ResolvedAt.Valid {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateChirpReport returns sql.ErrNoRows when the reporter already reported
+// the chirp, like the ON CONFLICT DO NOTHING insert. Reports without a
+// reporter never conflict.
+func (s *MemoryStore) CreateChirpReport(ctx context.Context, arg database.CreateChirpReportParams) (database.ChirpReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.chirpIndex(arg.ChirpID) < 0 {
+		return database.ChirpReport{}, errUnknownChirp
+	}
+	if arg.ReporterID.Valid {
+		if _, ok := s.users[arg.ReporterID.UUID]; !ok {
+			return database.ChirpReport{}, errUnknownUser
+		}
+		for _, report := range s.reports {
+			if report.ChirpID == arg.ChirpID && report.ReporterID == arg.ReporterID {
+				return database.ChirpReport{}, sql.ErrNoRows
+			}
+		}
+	}
+	report := database.ChirpReport{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		ChirpID:    arg.ChirpID,
+		ReporterID: arg.ReporterID,
+		Reason:     arg.Reason,
+		Details:    arg.Details,
+	}
+	s.reports = append(s.reports, report)
+	return report, nil
+}
+
+func (s *MemoryStore) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action := database.ModerationAction{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now(),
+		ModeratorID: arg.ModeratorID,
+		ChirpID:     arg.ChirpID,
+		AuthorID:    arg.AuthorID,
+		Action:      arg.Action,
+		Note:        arg.Note,
+	}
+	s.moderationActions = append(s.moderationActions, action)
+	return action, nil
+}
+
+func (s *MemoryStore) GetOpenReportQueue(ctx context.Context) ([]database.GetOpenReportQueueRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rows := map[uuid.UUID]*database.GetOpenReportQueueRow{}
+	var items []database.GetOpenReportQueueRow
+	for _, report := range s.reports {
+		if report.ResolvedAt.Valid {
+			continue
+		}
+		row, ok := rows[report.ChirpID]
+		if !ok {
+			chirp := s.chirps[s.chirpIndex(report.ChirpID)]
+			row = &database.GetOpenReportQueueRow{
+				ChirpID:         chirp.ID,
+				Body:            chirp.Body,
+				AuthorID:        chirp.UserID,
+				HiddenAt:        chirp.HiddenAt,
+				FirstReportedAt: report.CreatedAt,
+			}
+			rows[report.ChirpID] = row
+		}
+		row.ReportCount++
+		if !slices.Contains(row.Reasons, report.Reason) {
+			row.Reasons = append(row.Reasons, report.Reason)
+		}
+		if report.CreatedAt.Before(row.FirstReportedAt) {
+			row.FirstReportedAt = report.CreatedAt
+		}
+		if report.CreatedAt.After(row.LastReportedAt) {
+			row.LastReportedAt = report.CreatedAt
+		}
+	}
+	for _, row := range rows {
+		sort.Strings(row.Reasons)
+		items = append(items, *row)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ReportCount != items[j].ReportCount {
+			return items[i].ReportCount > items[j].ReportCount
+		}
+		return items[i].FirstReportedAt.Before(items[j].FirstReportedAt)
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) ResolveReportsForChirp(ctx context.Context, chirpID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.reports {
+		if s.reports[i].ChirpID == chirpID && !s.reports[i].ResolvedAt.Valid {
+			s.reports[i].ResolvedAt = now()
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateSpamDecision(ctx context.Context, arg database.CreateSpamDecisionParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spamDecisions = append(s.spamDecisions, database.SpamDecision{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		AuthorID:  arg.AuthorID,
+		ChirpID:   arg.ChirpID,
+		Body:      arg.Body,
+		Verdict:   arg.Verdict,
+		Score:     arg.Score,
+		Signals:   slices.Clone(arg.Signals),
+	})
+	return nil
+}
+
+func (s *MemoryStore) ListSpamDecisions(ctx context.Context, arg database.ListSpamDecisionsParams) ([]database.SpamDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.SpamDecision
+	for i := len(s.spamDecisions) - 1; i >= 0 && len(items) < int(arg.RowLimit); i-- {
+		decision := s.spamDecisions[i]
+		if arg.Verdict.Valid && decision.Verdict != arg.Verdict.String {
+			continue
+		}
+		decision.Signals = slices.Clone(decision.Signals)
+		items = append(items, decision)
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) DeleteProfanityWord(ctx context.Context, arg database.DeleteProfanityWordParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [2]string{arg.Locale, arg.Word}
+	if _, ok := s.profanityWords[key]; !ok {
+		return 0, nil
+	}
+	delete(s.profanityWords, key)
+	return 1, nil
+}
+
+func (s *MemoryStore) ListProfanityWords(ctx context.Context) ([]database.ProfanityWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.ProfanityWord
+	for _, word := range s.profanityWords {
+		items = append(items, word)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Locale != items[j].Locale {
+			return items[i].Locale < items[j].Locale
+		}
+		return items[i].Word < items[j].Word
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) UpsertProfanityWord(ctx context.Context, arg database.UpsertProfanityWordParams) (database.ProfanityWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [2]string{arg.Locale, arg.Word}
+	word, ok := s.profanityWords[key]
+	if !ok {
+		word = database.ProfanityWord{Locale: arg.Locale, Word: arg.Word, CreatedAt: time.Now()}
+	}
+	word.Action = arg.Action
+	word.UpdatedAt = time.Now()
+	s.profanityWords[key] = word
+	return word, nil
+}
+
+func (s *MemoryStore) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	diff := slices.Clone(arg.Diff)
+	if len(diff) == 0 {
+		diff = []byte("{}")
+	}
+	s.auditEvents = append(s.auditEvents, database.AuditEvent{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		ActorID:    arg.ActorID,
+		Action:     arg.Action,
+		TargetType: arg.TargetType,
+		TargetID:   arg.TargetID,
+		Ip:         arg.Ip,
+		UserAgent:  arg.UserAgent,
+		Diff:       diff,
+	})
+	return nil
+}
+
+func (s *MemoryStore) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []database.AuditEvent
+	for i := len(s.auditEvents) - 1; i >= 0 && len(items) < int(arg.RowLimit); i-- {
+		event := s.auditEvents[i]
+		switch {
+		case arg.ActorID.Valid && event.ActorID != arg.ActorID,
+			arg.TargetID.Valid && event.TargetID != arg.TargetID.String,
+			arg.Action.Valid && event.Action != arg.Action.String,
+			arg.Since.Valid && event.CreatedAt.Before(arg.Since.Time),
+			arg.Until.Valid && !event.CreatedAt.Before(arg.Until.Time):
+			continue
+		}
+		event.Diff = slices.Clone(event.Diff)
+		items = append(items, event)
+	}
+	return items, nil
+}
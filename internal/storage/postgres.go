@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/tracing"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes for transactions aborted over a conflict, which
+// succeed when run again.
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+// PostgresStore runs the sqlc queries against Postgres. Transactions are
+// serializable, so reads inside them can be relied on, and are retried when
+// Postgres aborts one in favor of a concurrent transaction.
+type PostgresStore struct {
+	*database.Queries
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		Queries: database.New(tracing.WrapDB(db, tracing.PostgreSQL)),
+		db:      db,
+	}
+}
+
+// InTx runs fn in a serializable transaction. Statements inside it are traced
+// like any other query.
+func (s *PostgresStore) InTx(ctx context.Context, fn func(Store) error) error {
+	return withRetry(ctx, isPostgresConflict, func() error {
+		return runTx(ctx, s.db, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(tx *sql.Tx) error {
+			return fn(database.New(tracing.WrapDB(tx, tracing.PostgreSQL)))
+		})
+	})
+}
+
+func isPostgresConflict(err error) bool {
+	pqErr := &pq.Error{}
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+}
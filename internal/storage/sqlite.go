@@ -0,0 +1,976 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are the goose migrations for SQLite databases, kept in
+// step with the Postgres ones in sql/schema.
+//
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrations embed.FS
+
+// sqliteTimeLayout is how the driver writes times with _time_format=sqlite.
+// Every stored time is UTC so the text sorts in time order.
+const sqliteTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// OpenSQLite opens the SQLite database at path, creating the file if needed
+// and applying any migrations it hasn't had.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := ConnectSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migrateSQLite(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating sqlite database: %w", err)
+	}
+	return db, nil
+}
+
+// ConnectSQLite opens the SQLite database at path without migrating it, for
+// the migrate command. SQLite allows a single writer, so the pool is limited
+// to one connection rather than failing writes with SQLITE_BUSY.
+func ConnectSQLite(path string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	dsn := path + sep + "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// SQLiteMigrations returns the embedded SQLite migrations.
+func SQLiteMigrations() (fs.FS, error) {
+	return fs.Sub(sqliteMigrations, "sqlite_migrations")
+}
+
+// NewSQLiteMigrationProvider returns a goose provider for the embedded SQLite
+// migrations. A SQLite file serves a single replica and SQLite already
+// serializes writers, so unlike Postgres no advisory lock is taken.
+func NewSQLiteMigrationProvider(db *sql.DB) (*goose.Provider, error) {
+	migrations, err := SQLiteMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectSQLite3, db, migrations)
+}
+
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	provider, err := NewSQLiteMigrationProvider(db)
+	if err != nil {
+		return err
+	}
+	_, err = provider.Up(ctx)
+	return err
+}
+
+// SQLiteStore runs the handlers' queries against a database opened with
+// OpenSQLite, so small deployments can run without Postgres.
+type SQLiteStore struct {
+	db database.DBTX
+	// conn begins transactions; nil for the Store passed to InTx
+	conn *sql.DB
+}
+
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: tracing.WrapDB(db, tracing.SQLite), conn: db}
+}
+
+// InTx runs fn in a transaction. Transactions take the write lock when they
+// begin and the pool has one connection, so they never conflict and are not
+// retried. fn must only use the Store it is given: the single connection is
+// held by the transaction until it ends.
+func (s *SQLiteStore) InTx(ctx context.Context, fn func(Store) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+	return runTx(ctx, s.conn, nil, func(tx *sql.Tx) error {
+		return fn(&SQLiteStore{db: tracing.WrapDB(tx, tracing.SQLite)})
+	})
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func utcNow() time.Time {
+	return time.Now().UTC()
+}
+
+// utc converts t for storage. Comparisons are done on the stored text, so
+// times in other zones would sort out of order.
+func utc(t sql.NullTime) sql.NullTime {
+	t.Time = t.Time.UTC()
+	return t
+}
+
+// jsonStrings stores a string slice as a JSON array.
+type jsonStrings []string
+
+func (j jsonStrings) value() string {
+	if j == nil {
+		return "[]"
+	}
+	data, _ := json.Marshal([]string(j))
+	return string(data)
+}
+
+func (j *jsonStrings) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("storage: cannot scan %T into a string slice", src)
+	}
+	return json.Unmarshal(data, (*[]string)(j))
+}
+
+// aggregateTime scans a timestamp returned by an aggregate, which the driver
+// reports as text because the column type is lost.
+type aggregateTime struct {
+	t *time.Time
+}
+
+func (a aggregateTime) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*a.t = v
+		return nil
+	case string:
+		t, err := time.Parse(sqliteTimeLayout, v)
+		if err != nil {
+			return err
+		}
+		*a.t = t
+		return nil
+	}
+	return fmt.Errorf("storage: cannot scan %T into a time", src)
+}
+
+const sqliteUserColumns = "id, created_at, updated_at, email, hashed_password, is_chirpy_red, role, suspended_until, shadowbanned"
+
+func scanUser(row rowScanner) (database.User, error) {
+	var i database.User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.Shadowbanned,
+	)
+	return i, err
+}
+
+const sqliteCreateUser = `-- name: CreateUser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+VALUES (?1, ?2, ?2, ?3, ?4)
+RETURNING ` + sqliteUserColumns
+
+func (s *SQLiteStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteCreateUser, uuid.New(), utcNow(), arg.Email, arg.HashedPassword))
+}
+
+const sqliteDeleteAllUsers = `-- name: DeleteAllUsers :exec
+DELETE FROM users
+`
+
+func (s *SQLiteStore) DeleteAllUsers(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, sqliteDeleteAllUsers)
+	return err
+}
+
+const sqliteGetUserByID = `-- name: GetUserByID :one
+SELECT ` + sqliteUserColumns + ` FROM users
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteGetUserByID, id))
+}
+
+const sqliteGetUserByUsername = `-- name: GetUserByUsername :one
+SELECT ` + sqliteUserColumns + ` FROM users
+WHERE email = ?1
+`
+
+func (s *SQLiteStore) GetUserByUsername(ctx context.Context, email string) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteGetUserByUsername, email))
+}
+
+const sqlitePromoteFirstAdmin = `-- name: PromoteFirstAdmin :execrows
+UPDATE users
+SET
+    role = 'admin',
+    updated_at = ?2
+WHERE id = ?1
+AND NOT EXISTS (
+    SELECT 1 FROM users WHERE role = 'admin'
+)
+`
+
+func (s *SQLiteStore) PromoteFirstAdmin(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := s.db.ExecContext(ctx, sqlitePromoteFirstAdmin, id, utcNow())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteSetUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET
+    role = ?2,
+    updated_at = ?3
+WHERE id = ?1
+RETURNING ` + sqliteUserColumns
+
+func (s *SQLiteStore) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteSetUserRole, arg.ID, arg.Role, utcNow()))
+}
+
+const sqliteSetUserShadowbanned = `-- name: SetUserShadowbanned :exec
+UPDATE users
+SET
+    shadowbanned = ?2,
+    updated_at = ?3
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteSetUserShadowbanned, arg.ID, arg.Shadowbanned, utcNow())
+	return err
+}
+
+const sqliteSuspendUser = `-- name: SuspendUser :exec
+UPDATE users
+SET
+    suspended_until = ?2,
+    updated_at = ?3
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) SuspendUser(ctx context.Context, arg database.SuspendUserParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteSuspendUser, arg.ID, utc(arg.SuspendedUntil), utcNow())
+	return err
+}
+
+const sqliteUpdateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+    email = ?2,
+    hashed_password = ?3,
+    updated_at = ?4
+WHERE id = ?1
+RETURNING ` + sqliteUserColumns
+
+func (s *SQLiteStore) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteUpdateUser, arg.ID, arg.Email, arg.HashedPassword, utcNow()))
+}
+
+const sqliteUpgradeToChirpyRed = `-- name: UpgradeToChirpyRed :one
+UPDATE users
+SET
+    is_chirpy_red = TRUE
+WHERE id = ?1
+RETURNING ` + sqliteUserColumns
+
+func (s *SQLiteStore) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, sqliteUpgradeToChirpyRed, id))
+}
+
+const sqliteChirpColumns = "chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.hidden_at, chirps.hidden_reason"
+
+func scanChirp(row rowScanner) (database.Chirp, error) {
+	var i database.Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.HiddenAt,
+		&i.HiddenReason,
+	)
+	return i, err
+}
+
+// queryChirps runs a query returning chirp rows.
+func (s *SQLiteStore) queryChirps(ctx context.Context, query string, args ...any) ([]database.Chirp, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.Chirp
+	for rows.Next() {
+		i, err := scanChirp(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteCreateChirp = `-- name: CreateChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+VALUES (?1, ?2, ?2, ?3, ?4)
+RETURNING ` + sqliteChirpColumns
+
+func (s *SQLiteStore) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	return scanChirp(s.db.QueryRowContext(ctx, sqliteCreateChirp, uuid.New(), utcNow(), arg.Body, arg.UserID))
+}
+
+const sqliteDeleteChirpByID = `-- name: DeleteChirpByID :exec
+DELETE FROM chirps
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, sqliteDeleteChirpByID, id)
+	return err
+}
+
+const sqliteGetAllChirps = `-- name: GetAllChirps :many
+SELECT ` + sqliteChirpColumns + ` FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= ?2)
+AND (NOT users.shadowbanned OR users.id = ?1)
+ORDER BY chirps.created_at ASC
+`
+
+func (s *SQLiteStore) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	return s.queryChirps(ctx, sqliteGetAllChirps, viewerID, utcNow())
+}
+
+const sqliteGetAllChirpsByUser = `-- name: GetAllChirpsByUser :many
+SELECT ` + sqliteChirpColumns + ` FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = ?1 AND chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= ?3)
+AND (NOT users.shadowbanned OR users.id = ?2)
+ORDER BY chirps.created_at ASC
+`
+
+func (s *SQLiteStore) GetAllChirpsByUser(ctx context.Context, arg database.GetAllChirpsByUserParams) ([]database.Chirp, error) {
+	return s.queryChirps(ctx, sqliteGetAllChirpsByUser, arg.UserID, arg.ViewerID, utcNow())
+}
+
+const sqliteGetChirpByID = `-- name: GetChirpByID :one
+SELECT ` + sqliteChirpColumns + ` FROM chirps
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	return scanChirp(s.db.QueryRowContext(ctx, sqliteGetChirpByID, id))
+}
+
+const sqliteGetChirpsChangedAt = `-- name: GetChirpsChangedAt :one
+SELECT MAX(
+    changed_at,
+    COALESCE((SELECT MAX(suspended_until) FROM users WHERE suspended_until <= ?1), changed_at)
+) FROM chirp_changes
+`
+
+func (s *SQLiteStore) GetChirpsChangedAt(ctx context.Context) (time.Time, error) {
+	var changedAt time.Time
+	err := s.db.QueryRowContext(ctx, sqliteGetChirpsChangedAt, utcNow()).Scan(aggregateTime{&changedAt})
+	return changedAt, err
+}
+
+const sqliteGetRecentChirpsByUser = `-- name: GetRecentChirpsByUser :many
+SELECT ` + sqliteChirpColumns + ` FROM chirps
+WHERE user_id = ?1 AND created_at >= ?2
+ORDER BY created_at DESC
+LIMIT ?3
+`
+
+func (s *SQLiteStore) GetRecentChirpsByUser(ctx context.Context, arg database.GetRecentChirpsByUserParams) ([]database.Chirp, error) {
+	return s.queryChirps(ctx, sqliteGetRecentChirpsByUser, arg.UserID, utc(arg.Since), arg.RowLimit)
+}
+
+const sqliteHideChirp = `-- name: HideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = COALESCE(hidden_at, ?3),
+    hidden_reason = ?2
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) HideChirp(ctx context.Context, arg database.HideChirpParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteHideChirp, arg.ID, arg.HiddenReason, utcNow())
+	return err
+}
+
+const sqliteUnhideChirp = `-- name: UnhideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = NULL,
+    hidden_reason = NULL
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) UnhideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, sqliteUnhideChirp, id)
+	return err
+}
+
+const sqliteRefreshTokenColumns = "created_at, updated_at, expires_at, revoked_at, user_id, id, token_prefix, token_hash"
+
+func scanRefreshToken(row rowScanner) (database.RefreshToken, error) {
+	var i database.RefreshToken
+	err := row.Scan(
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.UserID,
+		&i.ID,
+		&i.TokenPrefix,
+		&i.TokenHash,
+	)
+	return i, err
+}
+
+const sqliteCreateRefreshToken = `-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (id, token_prefix, token_hash, created_at, updated_at, expires_at, revoked_at, user_id)
+VALUES (?1, ?2, ?3, ?4, ?4, ?5, NULL, ?6)
+RETURNING ` + sqliteRefreshTokenColumns
+
+func (s *SQLiteStore) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCreateRefreshToken,
+		uuid.New(),
+		arg.TokenPrefix,
+		arg.TokenHash,
+		utcNow(),
+		utc(arg.ExpiresAt),
+		arg.UserID,
+	)
+	return scanRefreshToken(row)
+}
+
+const sqliteGetRefreshTokensByPrefix = `-- name: GetRefreshTokensByPrefix :many
+SELECT ` + sqliteRefreshTokenColumns + ` FROM refresh_tokens
+WHERE token_prefix = ?1
+`
+
+func (s *SQLiteStore) GetRefreshTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.RefreshToken, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteGetRefreshTokensByPrefix, tokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.RefreshToken
+	for rows.Next() {
+		i, err := scanRefreshToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteRevokeRefreshToken = `-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET
+    revoked_at = ?2,
+    updated_at = ?3
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteRevokeRefreshToken, arg.ID, utc(arg.RevokedAt), utc(arg.UpdatedAt))
+	return err
+}
+
+const sqlitePersonalAccessTokenColumns = "id, created_at, updated_at, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, user_id"
+
+func scanPersonalAccessToken(row rowScanner) (database.PersonalAccessToken, error) {
+	var i database.PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.TokenPrefix,
+		&i.TokenHash,
+		(*jsonStrings)(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+func (s *SQLiteStore) queryPersonalAccessTokens(ctx context.Context, query string, args ...any) ([]database.PersonalAccessToken, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.PersonalAccessToken
+	for rows.Next() {
+		i, err := scanPersonalAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteCreatePersonalAccessToken = `-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, created_at, updated_at, name, token_prefix, token_hash, scopes, expires_at, user_id)
+VALUES (?1, ?2, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+RETURNING ` + sqlitePersonalAccessTokenColumns
+
+func (s *SQLiteStore) CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCreatePersonalAccessToken,
+		uuid.New(),
+		utcNow(),
+		arg.Name,
+		arg.TokenPrefix,
+		arg.TokenHash,
+		jsonStrings(arg.Scopes).value(),
+		utc(arg.ExpiresAt),
+		arg.UserID,
+	)
+	return scanPersonalAccessToken(row)
+}
+
+const sqliteGetPersonalAccessTokensByPrefix = `-- name: GetPersonalAccessTokensByPrefix :many
+SELECT ` + sqlitePersonalAccessTokenColumns + ` FROM personal_access_tokens
+WHERE token_prefix = ?1
+`
+
+func (s *SQLiteStore) GetPersonalAccessTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.PersonalAccessToken, error) {
+	return s.queryPersonalAccessTokens(ctx, sqliteGetPersonalAccessTokensByPrefix, tokenPrefix)
+}
+
+const sqliteGetPersonalAccessTokensByUser = `-- name: GetPersonalAccessTokensByUser :many
+SELECT ` + sqlitePersonalAccessTokenColumns + ` FROM personal_access_tokens
+WHERE user_id = ?1 AND revoked_at IS NULL
+ORDER BY created_at ASC
+`
+
+func (s *SQLiteStore) GetPersonalAccessTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error) {
+	return s.queryPersonalAccessTokens(ctx, sqliteGetPersonalAccessTokensByUser, userID)
+}
+
+const sqliteRevokePersonalAccessToken = `-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET
+    revoked_at = ?3,
+    updated_at = ?3
+WHERE id = ?1 AND user_id = ?2 AND revoked_at IS NULL
+`
+
+func (s *SQLiteStore) RevokePersonalAccessToken(ctx context.Context, arg database.RevokePersonalAccessTokenParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx, sqliteRevokePersonalAccessToken, arg.ID, arg.UserID, utcNow())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteTouchPersonalAccessToken = `-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET
+    last_used_at = ?2
+WHERE id = ?1
+`
+
+func (s *SQLiteStore) TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, sqliteTouchPersonalAccessToken, id, utcNow())
+	return err
+}
+
+const sqliteCountOpenReportsForChirp = `-- name: CountOpenReportsForChirp :one
+SELECT COUNT(*) FROM chirp_reports
+WHERE chirp_id = ?1 AND resolved_at IS NULL
+`
+
+func (s *SQLiteStore) CountOpenReportsForChirp(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCountOpenReportsForChirp, chirpID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const sqliteCreateChirpReport = `-- name: CreateChirpReport :one
+INSERT INTO chirp_reports (id, created_at, chirp_id, reporter_id, reason, details)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING id, created_at, chirp_id, reporter_id, reason, details, resolved_at
+`
+
+func (s *SQLiteStore) CreateChirpReport(ctx context.Context, arg database.CreateChirpReportParams) (database.ChirpReport, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCreateChirpReport,
+		uuid.New(),
+		utcNow(),
+		arg.ChirpID,
+		arg.ReporterID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i database.ChirpReport
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ChirpID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const sqliteCreateModerationAction = `-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, chirp_id, author_id, action, note)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7)
+RETURNING id, created_at, moderator_id, chirp_id, author_id, action, note
+`
+
+func (s *SQLiteStore) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	row := s.db.QueryRowContext(ctx, sqliteCreateModerationAction,
+		uuid.New(),
+		utcNow(),
+		arg.ModeratorID,
+		arg.ChirpID,
+		arg.AuthorID,
+		arg.Action,
+		arg.Note,
+	)
+	var i database.ModerationAction
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ModeratorID,
+		&i.ChirpID,
+		&i.AuthorID,
+		&i.Action,
+		&i.Note,
+	)
+	return i, err
+}
+
+const sqliteGetOpenReportQueue = `-- name: GetOpenReportQueue :many
+SELECT
+    chirps.id AS chirp_id,
+    chirps.body,
+    chirps.user_id AS author_id,
+    chirps.hidden_at,
+    COUNT(chirp_reports.id) AS report_count,
+    JSON_GROUP_ARRAY(DISTINCT chirp_reports.reason) AS reasons,
+    MIN(chirp_reports.created_at) AS first_reported_at,
+    MAX(chirp_reports.created_at) AS last_reported_at
+FROM chirp_reports
+JOIN chirps ON chirps.id = chirp_reports.chirp_id
+WHERE chirp_reports.resolved_at IS NULL
+GROUP BY chirps.id
+ORDER BY report_count DESC, first_reported_at ASC
+`
+
+func (s *SQLiteStore) GetOpenReportQueue(ctx context.Context) ([]database.GetOpenReportQueueRow, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteGetOpenReportQueue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.GetOpenReportQueueRow
+	for rows.Next() {
+		var i database.GetOpenReportQueueRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.Body,
+			&i.AuthorID,
+			&i.HiddenAt,
+			&i.ReportCount,
+			(*jsonStrings)(&i.Reasons),
+			aggregateTime{&i.FirstReportedAt},
+			aggregateTime{&i.LastReportedAt},
+		); err != nil {
+			return nil, err
+		}
+		// ARRAY_AGG(DISTINCT ...) sorts its result; JSON_GROUP_ARRAY does not
+		sort.Strings(i.Reasons)
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteResolveReportsForChirp = `-- name: ResolveReportsForChirp :exec
+UPDATE chirp_reports
+SET
+    resolved_at = ?2
+WHERE chirp_id = ?1 AND resolved_at IS NULL
+`
+
+func (s *SQLiteStore) ResolveReportsForChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, sqliteResolveReportsForChirp, chirpID, utcNow())
+	return err
+}
+
+const sqliteCreateSpamDecision = `-- name: CreateSpamDecision :exec
+INSERT INTO spam_decisions (id, created_at, author_id, chirp_id, body, verdict, score, signals)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)
+`
+
+func (s *SQLiteStore) CreateSpamDecision(ctx context.Context, arg database.CreateSpamDecisionParams) error {
+	_, err := s.db.ExecContext(ctx, sqliteCreateSpamDecision,
+		uuid.New(),
+		utcNow(),
+		arg.AuthorID,
+		arg.ChirpID,
+		arg.Body,
+		arg.Verdict,
+		arg.Score,
+		jsonStrings(arg.Signals).value(),
+	)
+	return err
+}
+
+const sqliteListSpamDecisions = `-- name: ListSpamDecisions :many
+SELECT id, created_at, author_id, chirp_id, body, verdict, score, signals FROM spam_decisions
+WHERE (?1 IS NULL OR verdict = ?1)
+ORDER BY created_at DESC
+LIMIT ?2
+`
+
+func (s *SQLiteStore) ListSpamDecisions(ctx context.Context, arg database.ListSpamDecisionsParams) ([]database.SpamDecision, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListSpamDecisions, arg.Verdict, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.SpamDecision
+	for rows.Next() {
+		var i database.SpamDecision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.AuthorID,
+			&i.ChirpID,
+			&i.Body,
+			&i.Verdict,
+			&i.Score,
+			(*jsonStrings)(&i.Signals),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteDeleteProfanityWord = `-- name: DeleteProfanityWord :execrows
+DELETE FROM profanity_words
+WHERE locale = ?1 AND word = ?2
+`
+
+func (s *SQLiteStore) DeleteProfanityWord(ctx context.Context, arg database.DeleteProfanityWordParams) (int64, error) {
+	result, err := s.db.ExecContext(ctx, sqliteDeleteProfanityWord, arg.Locale, arg.Word)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const sqliteListProfanityWords = `-- name: ListProfanityWords :many
+SELECT locale, word, action, created_at, updated_at FROM profanity_words
+ORDER BY locale ASC, word ASC
+`
+
+func (s *SQLiteStore) ListProfanityWords(ctx context.Context) ([]database.ProfanityWord, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListProfanityWords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.ProfanityWord
+	for rows.Next() {
+		var i database.ProfanityWord
+		if err := rows.Scan(
+			&i.Locale,
+			&i.Word,
+			&i.Action,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sqliteUpsertProfanityWord = `-- name: UpsertProfanityWord :one
+INSERT INTO profanity_words (locale, word, action, created_at, updated_at)
+VALUES (?1, ?2, ?3, ?4, ?4)
+ON CONFLICT (locale, word) DO UPDATE
+SET
+    action = excluded.action,
+    updated_at = excluded.updated_at
+RETURNING locale, word, action, created_at, updated_at
+`
+
+func (s *SQLiteStore) UpsertProfanityWord(ctx context.Context, arg database.UpsertProfanityWordParams) (database.ProfanityWord, error) {
+	row := s.db.QueryRowContext(ctx, sqliteUpsertProfanityWord, arg.Locale, arg.Word, arg.Action, utcNow())
+	var i database.ProfanityWord
+	err := row.Scan(
+		&i.Locale,
+		&i.Word,
+		&i.Action,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const sqliteCreateAuditEvent = `-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, actor_id, action, target_type, target_id, ip, user_agent, diff)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)
+`
+
+func (s *SQLiteStore) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	diff := string(arg.Diff)
+	if diff == "" {
+		diff = "{}"
+	}
+	_, err := s.db.ExecContext(ctx, sqliteCreateAuditEvent,
+		uuid.New(),
+		utcNow(),
+		arg.ActorID,
+		arg.Action,
+		arg.TargetType,
+		arg.TargetID,
+		arg.Ip,
+		arg.UserAgent,
+		diff,
+	)
+	return err
+}
+
+const sqliteListAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, created_at, actor_id, action, target_type, target_id, ip, user_agent, diff FROM audit_events
+WHERE (?1 IS NULL OR actor_id = ?1)
+AND (?2 IS NULL OR target_id = ?2)
+AND (?3 IS NULL OR action = ?3)
+AND (?4 IS NULL OR created_at >= ?4)
+AND (?5 IS NULL OR created_at < ?5)
+ORDER BY created_at DESC
+LIMIT ?6
+`
+
+func (s *SQLiteStore) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	rows, err := s.db.QueryContext(ctx, sqliteListAuditEvents,
+		arg.ActorID,
+		arg.TargetID,
+		arg.Action,
+		utc(arg.Since),
+		utc(arg.Until),
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []database.AuditEvent
+	for rows.Next() {
+		var i database.AuditEvent
+		var diff []byte
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.Action,
+			&i.TargetType,
+			&i.TargetID,
+			&i.Ip,
+			&i.UserAgent,
+			&diff,
+		); err != nil {
+			return nil, err
+		}
+		i.Diff = diff
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
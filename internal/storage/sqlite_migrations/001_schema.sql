@@ -0,0 +1,150 @@
+-- +goose up
+-- The SQLite migrations mirror the Postgres migrations in sql/schema; this
+-- one matches them up to 015. UUIDs are stored as text and arrays as JSON
+-- text. Login attempts and rate limit buckets always use the in-memory stores
+-- with SQLite, so their tables are left out.
+--
+-- Databases created before the schema was versioned already have these
+-- tables, so this migration keeps IF NOT EXISTS and, for them, only records
+-- the version. Later migrations don't need it.
+
+CREATE TABLE IF NOT EXISTS users(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    email TEXT NOT NULL UNIQUE,
+    hashed_password TEXT DEFAULT 'unset',
+    is_chirpy_red BOOLEAN DEFAULT FALSE,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+    suspended_until TIMESTAMP DEFAULT NULL,
+    shadowbanned BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS chirps(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    body TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    hidden_at TIMESTAMP DEFAULT NULL,
+    hidden_reason TEXT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS chirps_user_id_idx ON chirps (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+    id TEXT PRIMARY KEY,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_token_prefix_idx ON refresh_tokens (token_prefix);
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    name TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    last_used_at TIMESTAMP DEFAULT NULL,
+    revoked_at TIMESTAMP DEFAULT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS personal_access_tokens_token_prefix_idx ON personal_access_tokens (token_prefix);
+
+CREATE TABLE IF NOT EXISTS chirp_reports(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id TEXT NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    -- Reports filed by the profanity filter have no reporter
+    reporter_id TEXT REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+        CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other', 'filter')),
+    details TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE TABLE IF NOT EXISTS moderation_actions(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id TEXT NOT NULL,
+    chirp_id TEXT NOT NULL,
+    author_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend')),
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS profanity_words(
+    locale TEXT NOT NULL,
+    word TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'flag', 'reject')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (locale, word)
+);
+
+CREATE TABLE IF NOT EXISTS spam_decisions(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    author_id TEXT NOT NULL,
+    chirp_id TEXT DEFAULT NULL,
+    body TEXT NOT NULL,
+    verdict TEXT NOT NULL CHECK (verdict IN ('allow', 'hold', 'reject')),
+    score INTEGER NOT NULL,
+    signals TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS spam_decisions_created_at_idx ON spam_decisions (created_at);
+
+CREATE TABLE IF NOT EXISTS audit_events(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor_id TEXT DEFAULT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    diff TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_target_id_idx ON audit_events (target_id, created_at);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update
+BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete
+BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose down
+DROP TABLE audit_events;
+DROP TABLE spam_decisions;
+DROP TABLE profanity_words;
+DROP TABLE moderation_actions;
+DROP TABLE chirp_reports;
+DROP TABLE personal_access_tokens;
+DROP TABLE refresh_tokens;
+DROP TABLE chirps;
+DROP TABLE users;
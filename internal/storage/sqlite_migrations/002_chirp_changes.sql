@@ -0,0 +1,60 @@
+-- +goose up
+-- Matches 016 in sql/schema. SQLite triggers fire once per row and event, so
+-- each change gets its own trigger.
+CREATE TABLE chirp_changes(
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    changed_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO chirp_changes (id, changed_at) VALUES (1, strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'));
+
+CREATE INDEX users_suspended_until_idx ON users (suspended_until);
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_insert_touch_chirp_changes
+AFTER INSERT ON chirps
+BEGIN
+    UPDATE chirp_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_update_touch_chirp_changes
+AFTER UPDATE ON chirps
+BEGIN
+    UPDATE chirp_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER chirps_delete_touch_chirp_changes
+AFTER DELETE ON chirps
+BEGIN
+    UPDATE chirp_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_update_touch_chirp_changes
+AFTER UPDATE OF suspended_until, shadowbanned ON users
+BEGIN
+    UPDATE chirp_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_delete_touch_chirp_changes
+AFTER DELETE ON users
+BEGIN
+    UPDATE chirp_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now');
+END;
+-- +goose StatementEnd
+
+-- +goose down
+DROP TRIGGER users_delete_touch_chirp_changes;
+DROP TRIGGER users_update_touch_chirp_changes;
+DROP TRIGGER chirps_delete_touch_chirp_changes;
+DROP TRIGGER chirps_update_touch_chirp_changes;
+DROP TRIGGER chirps_insert_touch_chirp_changes;
+DROP INDEX users_suspended_until_idx;
+DROP TABLE chirp_changes;
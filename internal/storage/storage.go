@@ -0,0 +1,85 @@
+// Package storage defines the queries the HTTP handlers run and the backends
+// that answer them. Postgres is served by the sqlc generated
+// database.Queries; the in-memory and SQLite stores mirror its semantics,
+// including sql.ErrNoRows for missing rows, so handlers behave the same on
+// every backend. Every backend can also group writes into a transaction.
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Store is the subset of database.Queries used by the handlers.
+type Store interface {
+	// Users
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	DeleteAllUsers(ctx context.Context) error
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetUserByUsername(ctx context.Context, email string) (database.User, error)
+	PromoteFirstAdmin(ctx context.Context, id uuid.UUID) (int64, error)
+	SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error)
+	SetUserShadowbanned(ctx context.Context, arg database.SetUserShadowbannedParams) error
+	SuspendUser(ctx context.Context, arg database.SuspendUserParams) error
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+	UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (database.User, error)
+
+	// Chirps
+	CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error)
+	DeleteChirpByID(ctx context.Context, id uuid.UUID) error
+	GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error)
+	GetAllChirpsByUser(ctx context.Context, arg database.GetAllChirpsByUserParams) ([]database.Chirp, error)
+	GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	GetChirpsChangedAt(ctx context.Context) (time.Time, error)
+	GetRecentChirpsByUser(ctx context.Context, arg database.GetRecentChirpsByUserParams) ([]database.Chirp, error)
+	HideChirp(ctx context.Context, arg database.HideChirpParams) error
+	UnhideChirp(ctx context.Context, id uuid.UUID) error
+
+	// Credentials
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error)
+	GetRefreshTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, arg database.RevokeRefreshTokenParams) error
+	CreatePersonalAccessToken(ctx context.Context, arg database.CreatePersonalAccessTokenParams) (database.PersonalAccessToken, error)
+	GetPersonalAccessTokensByPrefix(ctx context.Context, tokenPrefix string) ([]database.PersonalAccessToken, error)
+	GetPersonalAccessTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.PersonalAccessToken, error)
+	RevokePersonalAccessToken(ctx context.Context, arg database.RevokePersonalAccessTokenParams) (int64, error)
+	TouchPersonalAccessToken(ctx context.Context, id uuid.UUID) error
+
+	// Moderation
+	CountOpenReportsForChirp(ctx context.Context, chirpID uuid.UUID) (int64, error)
+	CreateChirpReport(ctx context.Context, arg database.CreateChirpReportParams) (database.ChirpReport, error)
+	CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error)
+	GetOpenReportQueue(ctx context.Context) ([]database.GetOpenReportQueueRow, error)
+	ResolveReportsForChirp(ctx context.Context, chirpID uuid.UUID) error
+	CreateSpamDecision(ctx context.Context, arg database.CreateSpamDecisionParams) error
+	ListSpamDecisions(ctx context.Context, arg database.ListSpamDecisionsParams) ([]database.SpamDecision, error)
+	DeleteProfanityWord(ctx context.Context, arg database.DeleteProfanityWordParams) (int64, error)
+	ListProfanityWords(ctx context.Context) ([]database.ProfanityWord, error)
+	UpsertProfanityWord(ctx context.Context, arg database.UpsertProfanityWordParams) (database.ProfanityWord, error)
+
+	// Audit log
+	CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error
+	ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error)
+}
+
+// TxStore is a Store that can run several statements as one transaction.
+type TxStore interface {
+	Store
+	// InTx runs fn against a Store whose writes are committed together when
+	// fn returns nil and rolled back otherwise. A transaction that loses a
+	// conflict with a concurrent one is run again, so fn must not have
+	// effects outside the Store it is given.
+	InTx(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store   = (*database.Queries)(nil)
+	_ TxStore = (*PostgresStore)(nil)
+	_ TxStore = (*MemoryStore)(nil)
+	_ TxStore = (*CachedStore)(nil)
+	_ TxStore = (*SQLiteStore)(nil)
+)
@@ -0,0 +1,789 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/cache"
+	"github.com/mattnickolaus/chirpy/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// stores runs fn against a fresh store of every kind that needs no server.
+func stores(t *testing.T, fn func(t *testing.T, s TxStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewMemoryStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "chirpy.db"))
+		if err != nil {
+			t.Fatalf("OpenSQLite errored: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		fn(t, NewSQLiteStore(db))
+	})
+	t.Run("cached", func(t *testing.T) {
+		fn(t, NewCachedStore(NewMemoryStore(), cache.NewLRU(100, 1<<20), time.Minute, nil))
+	})
+}
+
+func mustCreateUser(t *testing.T, s Store, email string) database.User {
+	t.Helper()
+	user, err := s.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          email,
+		HashedPassword: sql.NullString{String: "hash", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser errored: %v", err)
+	}
+	return user
+}
+
+func mustCreateChirp(t *testing.T, s Store, userID uuid.UUID, body string) database.Chirp {
+	t.Helper()
+	chirp, err := s.CreateChirp(context.Background(), database.CreateChirpParams{Body: body, UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateChirp errored: %v", err)
+	}
+	return chirp
+}
+
+func chirpBodies(chirps []database.Chirp) []string {
+	bodies := []string{}
+	for _, c := range chirps {
+		bodies = append(bodies, c.Body)
+	}
+	return bodies
+}
+
+func TestUsers(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		user := mustCreateUser(t, s, "walt@example.com")
+		if user.Role != "user" || user.IsChirpyRed.Bool || !user.CreatedAt.Valid {
+			t.Errorf("got: %+v; want a plain user with created_at set", user)
+		}
+
+		_, err := s.CreateUser(ctx, database.CreateUserParams{Email: "walt@example.com"})
+		if err == nil {
+			t.Errorf("duplicate email got: nil; want: error")
+		}
+
+		_, err = s.GetUserByID(ctx, uuid.New())
+		if !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("missing user got: %v; want: %v", err, sql.ErrNoRows)
+		}
+
+		byEmail, err := s.GetUserByUsername(ctx, "walt@example.com")
+		if err != nil || byEmail.ID != user.ID {
+			t.Errorf("got: %v, %v; want: %v", byEmail.ID, err, user.ID)
+		}
+
+		red, err := s.UpgradeToChirpyRed(ctx, user.ID)
+		if err != nil || !red.IsChirpyRed.Bool {
+			t.Errorf("got: %v, %v; want: chirpy red", red.IsChirpyRed, err)
+		}
+
+		// Only the first admin is bootstrapped
+		second := mustCreateUser(t, s, "jesse@example.com")
+		for _, tt := range []struct {
+			id   uuid.UUID
+			want int64
+		}{
+			{user.ID, 1},
+			{second.ID, 0},
+		} {
+			promoted, err := s.PromoteFirstAdmin(ctx, tt.id)
+			if err != nil || promoted != tt.want {
+				t.Errorf("PromoteFirstAdmin(%s) got: %v, %v; want: %v", tt.id, promoted, err, tt.want)
+			}
+		}
+		second, _ = s.GetUserByID(ctx, second.ID)
+		if second.Role != "user" {
+			t.Errorf("got: %v; want: user", second.Role)
+		}
+	})
+}
+
+func TestChirpVisibility(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		author := mustCreateUser(t, s, "author@example.com")
+		banned := mustCreateUser(t, s, "banned@example.com")
+		suspended := mustCreateUser(t, s, "suspended@example.com")
+
+		mustCreateChirp(t, s, author.ID, "visible")
+		hidden := mustCreateChirp(t, s, author.ID, "hidden")
+		mustCreateChirp(t, s, banned.ID, "shadowbanned")
+		mustCreateChirp(t, s, suspended.ID, "suspended")
+
+		err := s.HideChirp(ctx, database.HideChirpParams{ID: hidden.ID, HiddenReason: sql.NullString{String: "moderator", Valid: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.SetUserShadowbanned(ctx, database.SetUserShadowbannedParams{ID: banned.ID, Shadowbanned: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.SuspendUser(ctx, database.SuspendUserParams{
+			ID:             suspended.ID,
+			SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name   string
+			viewer uuid.NullUUID
+			want   []string
+		}{
+			{
+				name: "Test 1: Anonymous",
+				want: []string{"visible"},
+			},
+			{
+				name:   "Test 2: Shadowbanned Author",
+				viewer: uuid.NullUUID{UUID: banned.ID, Valid: true},
+				want:   []string{"visible", "shadowbanned"},
+			},
+		}
+		for _, tt := range tests {
+			chirps, err := s.GetAllChirps(ctx, tt.viewer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chirpBodies(chirps); !slices.Equal(got, tt.want) {
+				t.Errorf("%s got: %v; want: %v", tt.name, got, tt.want)
+			}
+		}
+
+		byUser, err := s.GetAllChirpsByUser(ctx, database.GetAllChirpsByUserParams{UserID: author.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := chirpBodies(byUser); !slices.Equal(got, []string{"visible"}) {
+			t.Errorf("by user got: %v; want: %v", got, []string{"visible"})
+		}
+
+		// The spam check still sees hidden chirps, newest first
+		recent, err := s.GetRecentChirpsByUser(ctx, database.GetRecentChirpsByUserParams{
+			UserID:   author.ID,
+			Since:    sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+			RowLimit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := chirpBodies(recent); !slices.Equal(got, []string{"hidden", "visible"}) {
+			t.Errorf("recent got: %v; want: %v", got, []string{"hidden", "visible"})
+		}
+	})
+}
+
+func TestChirpsChangedAt(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		author := mustCreateUser(t, s, "author@example.com")
+		var chirp database.Chirp
+
+		// Cases run in order against one store, so later cases see earlier writes
+		tests := []struct {
+			name   string
+			change func() error
+		}{
+			{
+				name: "Test 1: Create",
+				change: func() (err error) {
+					chirp, err = s.CreateChirp(ctx, database.CreateChirpParams{Body: "changes", UserID: author.ID})
+					return err
+				},
+			},
+			{
+				name: "Test 2: Hide",
+				change: func() error {
+					return s.HideChirp(ctx, database.HideChirpParams{ID: chirp.ID, HiddenReason: sql.NullString{String: "moderator", Valid: true}})
+				},
+			},
+			{
+				name:   "Test 3: Unhide",
+				change: func() error { return s.UnhideChirp(ctx, chirp.ID) },
+			},
+			{
+				name: "Test 4: Shadowban",
+				change: func() error {
+					return s.SetUserShadowbanned(ctx, database.SetUserShadowbannedParams{ID: author.ID, Shadowbanned: true})
+				},
+			},
+			{
+				name: "Test 5: Suspend",
+				change: func() error {
+					return s.SuspendUser(ctx, database.SuspendUserParams{
+						ID:             author.ID,
+						SuspendedUntil: sql.NullTime{Time: time.Now().Add(20 * time.Millisecond), Valid: true},
+					})
+				},
+			},
+			{
+				// Nothing is written when a suspension runs out
+				name: "Test 6: Suspension Runs Out",
+				change: func() error {
+					time.Sleep(30 * time.Millisecond)
+					return nil
+				},
+			},
+			{
+				name:   "Test 7: Delete",
+				change: func() error { return s.DeleteChirpByID(ctx, chirp.ID) },
+			},
+		}
+
+		for _, tt := range tests {
+			// SQLite keeps the time to the millisecond
+			time.Sleep(5 * time.Millisecond)
+			before := time.Now().Add(-time.Millisecond)
+			err := tt.change()
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			changedAt, err := s.GetChirpsChangedAt(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changedAt.Before(before) {
+				t.Errorf("%s got: %v; want at least: %v", tt.name, changedAt, before)
+			}
+		}
+	})
+}
+
+func TestReports(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		author := mustCreateUser(t, s, "author@example.com")
+		reporter := mustCreateUser(t, s, "reporter@example.com")
+		first := mustCreateChirp(t, s, author.ID, "first")
+		second := mustCreateChirp(t, s, author.ID, "second")
+
+		report := func(chirpID uuid.UUID, reporterID uuid.NullUUID, reason string) error {
+			_, err := s.CreateChirpReport(ctx, database.CreateChirpReportParams{ChirpID: chirpID, ReporterID: reporterID, Reason: reason})
+			return err
+		}
+		reporterID := uuid.NullUUID{UUID: reporter.ID, Valid: true}
+		if err := report(first.ID, reporterID, "spam"); err != nil {
+			t.Fatal(err)
+		}
+		if err := report(first.ID, reporterID, "hate"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("duplicate report got: %v; want: %v", err, sql.ErrNoRows)
+		}
+		// Reports from the filter have no reporter and never conflict
+		for range 2 {
+			if err := report(second.ID, uuid.NullUUID{}, "filter"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		queue, err := s.GetOpenReportQueue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queue) != 2 || queue[0].ChirpID != second.ID || queue[0].ReportCount != 2 || !slices.Equal(queue[0].Reasons, []string{"filter"}) {
+			t.Fatalf("got: %+v; want the second chirp first with two filter reports", queue)
+		}
+		if queue[0].FirstReportedAt.IsZero() || queue[0].LastReportedAt.Before(queue[0].FirstReportedAt) {
+			t.Errorf("got: %v to %v; want a valid report window", queue[0].FirstReportedAt, queue[0].LastReportedAt)
+		}
+
+		if err := s.ResolveReportsForChirp(ctx, second.ID); err != nil {
+			t.Fatal(err)
+		}
+		open, err := s.CountOpenReportsForChirp(ctx, second.ID)
+		if err != nil || open != 0 {
+			t.Errorf("got: %v, %v; want: 0", open, err)
+		}
+
+		// Deleting users cascades to chirps and reports
+		if err := s.DeleteAllUsers(ctx); err != nil {
+			t.Fatal(err)
+		}
+		queue, err = s.GetOpenReportQueue(ctx)
+		if err != nil || len(queue) != 0 {
+			t.Errorf("got: %v, %v; want an empty queue", queue, err)
+		}
+		if _, err := s.GetChirpByID(ctx, first.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("got: %v; want: %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		user := mustCreateUser(t, s, "walt@example.com")
+		pat, err := s.CreatePersonalAccessToken(ctx, database.CreatePersonalAccessTokenParams{
+			Name:        "ci",
+			TokenPrefix: "abcd1234",
+			TokenHash:   "hash",
+			Scopes:      []string{"chirps:read", "chirps:write"},
+			UserID:      user.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := s.GetPersonalAccessTokensByPrefix(ctx, "abcd1234")
+		if err != nil || len(found) != 1 || !slices.Equal(found[0].Scopes, []string{"chirps:read", "chirps:write"}) {
+			t.Errorf("got: %+v, %v; want the token with its scopes", found, err)
+		}
+
+		for _, tt := range []struct {
+			name   string
+			userID uuid.UUID
+			want   int64
+		}{
+			{"Test 1: Other User", uuid.New(), 0},
+			{"Test 2: Owner", user.ID, 1},
+			{"Test 3: Already Revoked", user.ID, 0},
+		} {
+			revoked, err := s.RevokePersonalAccessToken(ctx, database.RevokePersonalAccessTokenParams{ID: pat.ID, UserID: tt.userID})
+			if err != nil || revoked != tt.want {
+				t.Errorf("%s got: %v, %v; want: %v", tt.name, revoked, err, tt.want)
+			}
+		}
+
+		listed, err := s.GetPersonalAccessTokensByUser(ctx, user.ID)
+		if err != nil || len(listed) != 0 {
+			t.Errorf("got: %v, %v; want no active tokens", listed, err)
+		}
+	})
+}
+
+func TestAuditEvents(t *testing.T) {
+	stores(t, func(t *testing.T, s TxStore) {
+		ctx := context.Background()
+		actor := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+		for _, action := range []string{"login.failed", "login.succeeded", "user.updated"} {
+			err := s.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+				ActorID:    actor,
+				Action:     action,
+				TargetType: "user",
+				TargetID:   actor.UUID.String(),
+				Diff:       []byte(`{"email":{"to":"walt@example.com"}}`),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			name   string
+			params database.ListAuditEventsParams
+			want   []string
+		}{
+			{
+				name:   "Test 1: Newest First",
+				params: database.ListAuditEventsParams{RowLimit: 100},
+				want:   []string{"user.updated", "login.succeeded", "login.failed"},
+			},
+			{
+				name:   "Test 2: Limit",
+				params: database.ListAuditEventsParams{RowLimit: 1},
+				want:   []string{"user.updated"},
+			},
+			{
+				name: "Test 3: Action Filter",
+				params: database.ListAuditEventsParams{
+					Action:   sql.NullString{String: "login.failed", Valid: true},
+					RowLimit: 100,
+				},
+				want: []string{"login.failed"},
+			},
+			{
+				name: "Test 4: Other Actor",
+				params: database.ListAuditEventsParams{
+					ActorID:  uuid.NullUUID{UUID: uuid.New(), Valid: true},
+					RowLimit: 100,
+				},
+				want: []string{},
+			},
+			{
+				name: "Test 5: Until Before Every Event",
+				params: database.ListAuditEventsParams{
+					Until:    sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+					RowLimit: 100,
+				},
+				want: []string{},
+			},
+		}
+		for _, tt := range tests {
+			events, err := s.ListAuditEvents(ctx, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, e := range events {
+				got = append(got, e.Action)
+				if string(e.Diff) != `{"email":{"to":"walt@example.com"}}` {
+					t.Errorf("diff got: %s", e.Diff)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s got: %v; want: %v", tt.name, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestInTx(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		fnErr     error
+		wantUsers int
+	}{
+		{
+			name:      "Test 1: Commit",
+			wantUsers: 2,
+		},
+		{
+			name:      "Test 2: Roll Back",
+			fnErr:     errAbort,
+			wantUsers: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores(t, func(t *testing.T, s TxStore) {
+				ctx := context.Background()
+				err := s.InTx(ctx, func(tx Store) error {
+					author := mustCreateUser(t, tx, "author@example.com")
+					mustCreateUser(t, tx, "reader@example.com")
+					mustCreateChirp(t, tx, author.ID, "written in a transaction")
+					return tt.fnErr
+				})
+				if !errors.Is(err, tt.fnErr) {
+					t.Fatalf("got: %v; want: %v", err, tt.fnErr)
+				}
+
+				users := 0
+				for _, email := range []string{"author@example.com", "reader@example.com"} {
+					_, err := s.GetUserByUsername(ctx, email)
+					if err == nil {
+						users++
+					}
+				}
+				if users != tt.wantUsers {
+					t.Errorf("got: %v; want: %v", users, tt.wantUsers)
+				}
+			})
+		})
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	names, err := fs.Glob(sqliteMigrations, "sqlite_migrations/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		prepare  func(t *testing.T, path string)
+		wantUser bool
+	}{
+		{
+			name:    "Test 1: New Database",
+			prepare: func(t *testing.T, path string) {},
+		},
+		{
+			name:     "Test 2: Reopened",
+			wantUser: true,
+			prepare: func(t *testing.T, path string) {
+				db, err := OpenSQLite(ctx, path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				mustCreateUser(t, NewSQLiteStore(db), "kept@example.com")
+				db.Close()
+			},
+		},
+		{
+			name:     "Test 3: Created Before Versioning",
+			wantUser: true,
+			prepare: func(t *testing.T, path string) {
+				db, err := sql.Open("sqlite", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer db.Close()
+				_, err = db.Exec(`CREATE TABLE users(id TEXT PRIMARY KEY, created_at TIMESTAMP, updated_at TIMESTAMP, email TEXT NOT NULL UNIQUE, hashed_password TEXT DEFAULT 'unset', is_chirpy_red BOOLEAN DEFAULT FALSE, role TEXT NOT NULL DEFAULT 'user', suspended_until TIMESTAMP DEFAULT NULL, shadowbanned BOOLEAN NOT NULL DEFAULT FALSE);
+					INSERT INTO users (id, email) VALUES ('00000000-0000-0000-0000-000000000001', 'kept@example.com')`)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chirpy.db")
+			tt.prepare(t, path)
+
+			db, err := OpenSQLite(ctx, path)
+			if err != nil {
+				t.Fatalf("OpenSQLite errored: %v", err)
+			}
+			defer db.Close()
+
+			var version int
+			err = db.QueryRowContext(ctx, "SELECT MAX(version_id) FROM goose_db_version").Scan(&version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != len(names) {
+				t.Errorf("version got: %v; want: %v", version, len(names))
+			}
+			_, err = NewSQLiteStore(db).GetUserByUsername(ctx, "kept@example.com")
+			if found := err == nil; found != tt.wantUser {
+				t.Errorf("user found got: %v (%v); want: %v", found, err, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	conflict := &pq.Error{Code: pqSerializationFailure}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "Test 1: First Try",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "Test 2: Conflict Then Success",
+			errs:      []error{conflict, fmt.Errorf("creating chirp: %w", conflict), nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "Test 3: Other Error",
+			errs:      []error{sql.ErrNoRows},
+			wantErr:   sql.ErrNoRows,
+			wantCalls: 1,
+		},
+		{
+			name:      "Test 4: Gives Up",
+			errs:      slices.Repeat([]error{conflict}, maxTxAttempts+1),
+			wantErr:   conflict,
+			wantCalls: maxTxAttempts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(context.Background(), isPostgresConflict, func() error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got: %v; want: %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls got: %v; want: %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name      string
+		write     func(s TxStore, user database.User, chirp database.Chirp) error
+		wantHit   bool
+		wantEmail string
+		wantChirp bool
+	}{
+		{
+			name:      "Test 1: No Write",
+			write:     func(s TxStore, user database.User, chirp database.Chirp) error { return nil },
+			wantHit:   true,
+			wantEmail: "author@example.com",
+			wantChirp: true,
+		},
+		{
+			name: "Test 2: Write",
+			write: func(s TxStore, user database.User, chirp database.Chirp) error {
+				_, err := s.UpdateUser(ctx, database.UpdateUserParams{ID: user.ID, Email: "new@example.com"})
+				if err != nil {
+					return err
+				}
+				return s.DeleteChirpByID(ctx, chirp.ID)
+			},
+			wantEmail: "new@example.com",
+		},
+		{
+			name: "Test 3: Committed Transaction",
+			write: func(s TxStore, user database.User, chirp database.Chirp) error {
+				return s.InTx(ctx, func(tx Store) error {
+					_, err := tx.UpdateUser(ctx, database.UpdateUserParams{ID: user.ID, Email: "new@example.com"})
+					if err != nil {
+						return err
+					}
+					return tx.DeleteChirpByID(ctx, chirp.ID)
+				})
+			},
+			wantEmail: "new@example.com",
+		},
+		{
+			name: "Test 4: Rolled Back Transaction",
+			write: func(s TxStore, user database.User, chirp database.Chirp) error {
+				err := s.InTx(ctx, func(tx Store) error {
+					_, err := tx.UpdateUser(ctx, database.UpdateUserParams{ID: user.ID, Email: "new@example.com"})
+					if err != nil {
+						return err
+					}
+					return errAbort
+				})
+				if errors.Is(err, errAbort) {
+					return nil
+				}
+				return err
+			},
+			wantHit:   true,
+			wantEmail: "author@example.com",
+			wantChirp: true,
+		},
+		{
+			name:  "Test 5: Reset",
+			write: func(s TxStore, user database.User, chirp database.Chirp) error { return s.DeleteAllUsers(ctx) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups := map[string]int{}
+			s := NewCachedStore(NewMemoryStore(), cache.NewLRU(100, 1<<20), time.Minute, func(table, result string) {
+				lookups[result]++
+			})
+			user := mustCreateUser(t, s, "author@example.com")
+			chirp := mustCreateChirp(t, s, user.ID, "cache me")
+			// Fill the cache
+			s.GetUserByID(ctx, user.ID)
+			s.GetChirpByID(ctx, chirp.ID)
+
+			err := tt.write(s, user, chirp)
+			if err != nil {
+				t.Fatalf("write errored: %v", err)
+			}
+			clear(lookups)
+
+			gotUser, err := s.GetUserByID(ctx, user.ID)
+			if gotUser.Email != tt.wantEmail {
+				t.Errorf("email got: %v, %v; want: %v", gotUser.Email, err, tt.wantEmail)
+			}
+			_, err = s.GetChirpByID(ctx, chirp.ID)
+			if gotChirp := err == nil; gotChirp != tt.wantChirp {
+				t.Errorf("chirp found got: %v, %v; want: %v", gotChirp, err, tt.wantChirp)
+			}
+			if gotHit := lookups[CacheHit] == 2; gotHit != tt.wantHit {
+				t.Errorf("lookups got: %v; want all hits: %v", lookups, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestCachedStoreOmitsPasswordHash(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(100, 1<<20)
+	s := NewCachedStore(NewMemoryStore(), c, time.Minute, nil)
+	user := mustCreateUser(t, s, "author@example.com")
+
+	// The miss that fills the cache and the hit after it look the same
+	for range 2 {
+		got, err := s.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Email != user.Email || got.HashedPassword.Valid {
+			t.Errorf("got: %+v; want %s without a password hash", got, user.Email)
+		}
+	}
+
+	data, found, err := c.Get(ctx, userKey(user.ID))
+	if err != nil || !found {
+		t.Fatalf("cached user got: %v, %v; want found", found, err)
+	}
+	if bytes.Contains(data, []byte("HashedPassword")) {
+		t.Errorf("cached user has a password hash: %s", data)
+	}
+}
+
+// blockingStore holds GetUserByID until release is closed.
+type blockingStore struct {
+	TxStore
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *blockingStore) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.calls.Add(1)
+	<-s.release
+	return s.TxStore.GetUserByID(ctx, id)
+}
+
+func TestCachedStoreSharesMisses(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStore()
+	user := mustCreateUser(t, memory, "author@example.com")
+	blocking := &blockingStore{TxStore: memory, release: make(chan struct{})}
+	s := NewCachedStore(blocking, cache.NewLRU(100, 1<<20), time.Minute, nil)
+
+	const readers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for range readers {
+		wg.Go(func() {
+			_, err := s.GetUserByID(ctx, user.ID)
+			errs <- err
+		})
+	}
+	// Let every reader reach the shared query before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(blocking.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetUserByID errored: %v", err)
+		}
+	}
+	if calls := blocking.calls.Load(); calls != 1 {
+		t.Errorf("queries got: %v; want: 1", calls)
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"math/rand/v2"
+	"time"
+)
+
+// NOTE: Conflicting transactions usually succeed on the second try; the cap
+// only stops a hot row from retrying forever
+const (
+	maxTxAttempts = 5
+	txRetryDelay  = 10 * time.Millisecond
+)
+
+// withRetry calls attempt until it succeeds, fails with an error retryable
+// rejects or has been tried maxTxAttempts times. The delay between tries
+// doubles and is jittered so conflicting callers don't collide again.
+func withRetry(ctx context.Context, retryable func(error) bool, attempt func() error) error {
+	delay := txRetryDelay
+	for tries := 1; ; tries++ {
+		err := attempt()
+		if err == nil || tries == maxTxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(delay/2 + rand.N(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// runTx runs fn in a transaction on db, committing it if fn succeeds and
+// rolling it back otherwise.
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	// A no-op once committed; covers fn failing or panicking
+	defer tx.Rollback()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mattnickolaus/chirpy/internal/httpx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware continues the trace from the caller's traceparent header, or
+// starts a new one, and wraps the request in a server span. The span is named
+// after the route pattern the ServeMux matched, so it must run outside the
+// mux; running it outside the logging middleware tags log lines with the
+// trace ID.
+func Middleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := httpx.NewResponseRecorder(w)
+		httpx.ServeWithContext(ctx, next, rec, r)
+
+		// Patterns without a method, like "/app/", still get one in the name
+		if r.Pattern != "" {
+			route := r.Pattern
+			if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+				route = path
+			}
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.Status()))
+		if rec.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.Status()))
+		}
+	})
+}
@@ -0,0 +1,90 @@
+// Package tracing sets up OpenTelemetry tracing and provides the HTTP
+// middleware and database wrapper that create Chirpy's spans.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	tracerName  = "github.com/mattnickolaus/chirpy"
+	serviceName = "chirpy"
+)
+
+// Exporter selects where finished spans are sent.
+type Exporter string
+
+const (
+	// ExporterNone records no spans, but trace context is still propagated.
+	ExporterNone Exporter = "none"
+	// ExporterOTLP sends spans over OTLP/HTTP. The endpoint and headers come
+	// from the standard OTEL_EXPORTER_OTLP_* environment variables.
+	ExporterOTLP Exporter = "otlp"
+	// ExporterStdout writes spans as JSON, for local debugging and tests.
+	ExporterStdout Exporter = "stdout"
+)
+
+func ParseExporter(s string) (Exporter, error) {
+	switch Exporter(s) {
+	case "":
+		return ExporterNone, nil
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+		return Exporter(s), nil
+	default:
+		return "", fmt.Errorf("invalid trace exporter %q: accepts none, otlp or stdout", s)
+	}
+}
+
+// Setup installs the W3C trace-context propagator and a global tracer
+// provider using exporter. Stdout spans are written to w. The returned
+// function flushes buffered spans and should be called before exiting.
+func Setup(ctx context.Context, exporter Exporter, w io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter: %w", exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
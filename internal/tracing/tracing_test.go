@@ -0,0 +1,225 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{
+			name:     "Test 1: Off By Default",
+			exporter: "",
+		},
+		{
+			name:     "Test 2: Stdout",
+			exporter: "stdout",
+		},
+		{
+			name:     "Test 3: Unknown Exporter",
+			exporter: "jaeger",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter, err := ParseExporter(tt.exporter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error: %v; want error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var buf bytes.Buffer
+			shutdown, err := Setup(context.Background(), exporter, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, span := otel.Tracer(tracerName).Start(context.Background(), "test-span")
+			span.End()
+			shutdown(context.Background())
+
+			exported := strings.Contains(buf.String(), "test-span")
+			if exported != (exporter == ExporterStdout) {
+				t.Errorf("got span exported: %v; want: %v", exported, exporter == ExporterStdout)
+			}
+		})
+	}
+}
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/app/", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Middleware(mux)
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  codes.Code
+	}{
+		{
+			name:        "Test 1: Continues Incoming Trace",
+			path:        "/api/chirps/1",
+			traceparent: traceparent,
+			wantName:    "GET /api/chirps/{chirpID}",
+			wantStatus:  codes.Error,
+		},
+		{
+			name:       "Test 2: Pattern Without Method",
+			path:       "/app/index.html",
+			wantName:   "GET /app/",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "Test 3: Unmatched Path",
+			path:       "/random/path",
+			wantName:   "GET",
+			wantStatus: codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans; want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("got: %v; want: %v", span.Name(), tt.wantName)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("got: %v; want: %v", span.Status().Code, tt.wantStatus)
+			}
+			if tt.traceparent != "" && span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("got trace ID %v; want the incoming one", span.SpanContext().TraceID())
+			}
+		})
+	}
+}
+
+type fakeDB struct {
+	database.DBTX
+	err error
+}
+
+func (f *fakeDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, f.err
+}
+
+func TestDB(t *testing.T) {
+	recorder := setupRecorder(t)
+
+	tests := []struct {
+		name       string
+		system     attribute.KeyValue
+		query      string
+		err        error
+		wantName   string
+		wantStatus codes.Code
+	}{
+		{
+			name:       "Test 1: Named After sqlc Query",
+			system:     PostgreSQL,
+			query:      "-- name: DeleteChirpByID :exec\nDELETE FROM chirps WHERE id = $1",
+			wantName:   "DeleteChirpByID",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "Test 2: Unnamed Query",
+			system:     PostgreSQL,
+			query:      "SELECT 1",
+			wantName:   "query",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "Test 3: Failed Query",
+			system:     SQLite,
+			query:      "-- name: CreateChirp :one\nINSERT INTO chirps",
+			err:        errors.New("connection refused"),
+			wantName:   "CreateChirp",
+			wantStatus: codes.Error,
+		},
+		{
+			name:       "Test 4: No Rows Is Not A Failure",
+			system:     SQLite,
+			query:      "-- name: GetChirpByID :one\nSELECT",
+			err:        sql.ErrNoRows,
+			wantName:   "GetChirpByID",
+			wantStatus: codes.Unset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			WrapDB(&fakeDB{err: tt.err}, tt.system).ExecContext(context.Background(), tt.query)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans; want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("got: %v; want: %v", span.Name(), tt.wantName)
+			}
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("got: %v; want: %v", span.Status().Code, tt.wantStatus)
+			}
+			for _, attr := range span.Attributes() {
+				if attr.Key == semconv.DBQueryTextKey && attr.Value.AsString() != tt.query {
+					t.Errorf("got: %v; want: %v", attr.Value.AsString(), tt.query)
+				}
+				if attr.Key == semconv.DBSystemNameKey && attr != tt.system {
+					t.Errorf("got: %v; want: %v", attr.Value.AsString(), tt.system.Value.AsString())
+				}
+			}
+		})
+	}
+}
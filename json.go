@@ -2,16 +2,32 @@ package main
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/logging"
 )
 
-func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	if err != nil {
-		log.Printf("%v: %v", msg, err)
+// respondWithError sends msg to the client and logs err with the request's
+// logger. Server errors are logged at error level, everything else at info.
+// Bodies cut off by limitBody are reported as 413 whatever the handler chose.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, msg string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		code = http.StatusRequestEntityTooLarge
+		msg = "Request body too large"
 	}
-	if code > 499 {
-		log.Printf("Respondign with 5XX error: %s", msg)
+
+	if err != nil || code > 499 {
+		level := slog.LevelInfo
+		if code > 499 {
+			level = slog.LevelError
+		}
+		logging.FromContext(r.Context()).Log(r.Context(), level, msg, "status", code, "error", err)
 	}
 
 	type errResponse struct {
@@ -20,12 +36,20 @@ func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
 	respondWithJSON(w, code, errResponse{Error: msg})
 }
 
+// respondWithTooManyRequests sends a 429 with Retry-After rounded up to whole
+// seconds.
+func respondWithTooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, msg string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	respondWithError(w, r, http.StatusTooManyRequests, msg, nil)
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		slog.Error("Error marshalling JSON", "error", err)
 		w.WriteHeader(500)
 		return
 	}
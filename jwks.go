@@ -0,0 +1,16 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+)
+
+func (cfg *apiConfig) getJWKS(w http.ResponseWriter, r *http.Request) {
+	type jwksResponse struct {
+		Keys []auth.JWK `json:"keys"`
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, jwksResponse{Keys: cfg.jwtKeys.JWKS()})
+}
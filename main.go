@@ -1,26 +1,72 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
-	"net/http"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/compress"
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/cors"
 	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/lockout"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+	"github.com/mattnickolaus/chirpy/internal/metrics"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+	"github.com/mattnickolaus/chirpy/internal/ratelimit"
+	"github.com/mattnickolaus/chirpy/internal/service"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+	"github.com/mattnickolaus/chirpy/internal/tracing"
 
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	secret         string
-	polkaApiKey    string
+	fileserverHits atomic.Uint64
+	metrics        *metrics.Metrics
+	db             storage.Store
+	// service applies the business rules shared with other transports
+	service *service.Service
+	// sqlDB is nil for the in-memory store
+	sqlDB *sql.DB
+	// schemaVersion is the migration the database must have reached to serve
+	schemaVersion    int64
+	platform         string
+	jwtKeys          *auth.KeySet
+	loginGuard       *lockout.Guard
+	lockoutNotifier  lockout.Notifier
+	rateLimiter      *ratelimit.Limiter
+	rateLimits       rateLimitPolicies
+	trustedProxies   []netip.Prefix
+	cors             cors.Options
+	headers          headerPolicies
+	profanity        *profanity.Filter
+	profanitySources []profanity.Source
+	polkaApiKey      string
+	accessTokenTTL   time.Duration
+	// background tracks goroutines that must finish before shutdown
+	background sync.WaitGroup
+	// workers are the long-running background goroutines reported by the
+	// health checks
+	workers      map[string]*backgroundWorker
+	shuttingDown atomic.Bool
 }
 
 type User struct {
@@ -31,6 +77,7 @@ type User struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Role         string    `json:"role"`
 }
 
 type Chirp struct {
@@ -44,56 +91,207 @@ type Chirp struct {
 func main() {
 	godotenv.Load()
 
-	const port = "8080"
-	const filePath = "."
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	dbURL := os.Getenv("DB_URL")
-	platformType := os.Getenv("PLATFORM")
-	secret := os.Getenv("SECRET")
-	polkaKey := os.Getenv("POLKA_KEY")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap-admin" {
+		err := runBootstrapAdmin(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	db, err := sql.Open("postgres", dbURL)
+	fs := flag.NewFlagSet("chirpy", flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration, with secrets redacted, and exit")
+	conf, err := config.Load(fs, os.Args[1:], os.Getenv)
 	if err != nil {
-		log.Fatal("Error opening databse")
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		out, err := conf.YAML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error printing configuration: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
 	}
 
-	dbQueries := database.New(db)
+	logger, err := logging.New(os.Stdout, conf.Log.Format, conf.Log.Level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring logger: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	logger.Debug("Loaded configuration", "config", conf.Redacted())
 
-	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       platformType,
-		secret:         secret,
-		polkaApiKey:    polkaKey,
+	traceExporter, err := tracing.ParseExporter(conf.Tracing.Exporter)
+	if err != nil {
+		fatal("Error parsing tracing exporter", err)
+	}
+	shutdownTracing, err := tracing.Setup(ctx, traceExporter, os.Stdout)
+	if err != nil {
+		fatal("Error setting up tracing", err)
 	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.middlewareMetric(http.FileServer(http.Dir(filePath)))))
+	trustedProxies, err := parseTrustedProxies(strings.Join(conf.Server.TrustedProxies, ","))
+	if err != nil {
+		fatal("Error parsing trusted proxies", err)
+	}
 
-	mux.HandleFunc("GET /api/healthz", healthHandler)
+	spamPolicy := spam.DefaultConfig
+	spamPolicy.HoldScore = conf.Moderation.SpamHoldScore
+	spamPolicy.RejectScore = conf.Moderation.SpamRejectScore
 
-	mux.HandleFunc("POST /api/users", apiCfg.createUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.updateUser)
-	mux.HandleFunc("POST /api/login", apiCfg.login)
+	store, db, err := openStore(ctx, conf.Database, logger)
+	if err != nil {
+		fatal("Error opening database", err, "driver", conf.Database.Driver)
+	}
+	schemaVersion, err := latestMigration(conf.Database.Driver)
+	if err != nil {
+		fatal("Error reading embedded migrations", err)
+	}
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.upgradeToChirpyRed)
+	appMetrics := metrics.New()
+	if db != nil {
+		appMetrics.RegisterDB(db, "chirpy")
+	}
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.createChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.getAllChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getChirp)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirp)
+	store, closeCache, err := cacheStore(store, conf.Cache, appMetrics)
+	if err != nil {
+		fatal("Error configuring cache", err, "backend", conf.Cache.Backend)
+	}
 
-	mux.HandleFunc("POST /api/refresh", apiCfg.refreshAccessToken)
-	mux.HandleFunc("POST /api/revoke", apiCfg.revokeRefreshToken)
+	// Asymmetric keys let other services verify tokens through the JWKS
+	// endpoint. Without them, fall back to signing with the shared secret.
+	jwtKeys := auth.NewHMACKeySet(conf.Auth.Secret)
+	if conf.Auth.JWTKeysDir != "" {
+		jwtKeys, err = auth.LoadKeySet(conf.Auth.JWTKeysDir)
+		if err != nil {
+			fatal("Error loading JWT keys", err)
+		}
+	}
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.resetHits)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.numberOfHits)
+	// Counters kept in memory are lost on restart and not shared between
+	// replicas, so multi-replica deployments should use Postgres. Validation
+	// only allows the Postgres stores with the Postgres driver.
+	var attemptStore lockout.Store = lockout.NewMemoryStore()
+	if conf.Auth.LoginAttemptStore == "postgres" {
+		attemptStore = lockout.NewPostgresStore(database.New(tracing.WrapDB(db, tracing.PostgreSQL)))
+	}
 
-	server := http.Server{
-		Handler: mux,
-		Addr:    ":" + port,
+	var bucketStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if conf.RateLimit.Store == "postgres" {
+		bucketStore = ratelimit.NewPostgresStore(db, database.New(tracing.WrapDB(db, tracing.PostgreSQL)))
 	}
 
-	log.Printf("Servering file at '%s' on port %s ...\n", filePath, port)
-	log.Fatal(http.ListenAndServe(server.Addr, server.Handler))
+	var lockoutNotifier lockout.Notifier
+	if conf.Auth.LockoutNotifyURL != "" {
+		lockoutNotifier = lockout.WebhookNotifier{URL: conf.Auth.LockoutNotifyURL}
+	}
+
+	apiCfg := apiConfig{
+		fileserverHits:  atomic.Uint64{},
+		metrics:         appMetrics,
+		db:              store,
+		sqlDB:           db,
+		schemaVersion:   schemaVersion,
+		platform:        conf.Platform,
+		jwtKeys:         jwtKeys,
+		loginGuard:      lockout.NewGuard(attemptStore, lockout.DefaultAccountPolicy, lockout.DefaultIPPolicy),
+		lockoutNotifier: lockoutNotifier,
+		rateLimiter:     ratelimit.NewLimiter(bucketStore),
+		rateLimits:      newRateLimitPolicies(conf.RateLimit),
+		cors:            newCORSOptions(conf.CORS),
+		headers:         newHeaderPolicies(conf.Security),
+		trustedProxies:  trustedProxies,
+		polkaApiKey:     conf.Polka.APIKey,
+		accessTokenTTL:  conf.Auth.AccessTokenTTL,
+	}
+
+	// The hit counter is reset by /admin/reset, which Prometheus treats like a
+	// restart of the counter
+	appMetrics.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "chirpy",
+		Name:      "fileserver_hits_total",
+		Help:      "Requests for files under /app/ since the last reset.",
+	}, func() float64 {
+		return float64(apiCfg.fileserverHits.Load())
+	}))
+
+	// Word lists are layered: built-in words, then PROFANITY_DIR files, then
+	// words managed through the admin API.
+	apiCfg.profanity = profanity.NewFilter(profanity.DefaultWords)
+	apiCfg.profanitySources = []profanity.Source{profanity.StaticSource(profanity.DefaultWords)}
+	if conf.Moderation.ProfanityDir != "" {
+		apiCfg.profanitySources = append(apiCfg.profanitySources, profanity.DirSource(conf.Moderation.ProfanityDir))
+	}
+	apiCfg.profanitySources = append(apiCfg.profanitySources, apiCfg.profanityDatabaseSource)
+	if err := apiCfg.reloadProfanity(ctx); err != nil {
+		logger.Error("Error loading profanity lists, using built-in list", "error", err)
+	}
+	apiCfg.service = service.New(store, service.Config{
+		ChirpMaxLength:      conf.Chirps.MaxLength,
+		ReportHideThreshold: conf.Moderation.ReportHideThreshold,
+		Profanity:           apiCfg.profanity,
+		Spam:                spamPolicy,
+		JWTKeys:             jwtKeys,
+		AccessTokenTTL:      conf.Auth.AccessTokenTTL,
+		RefreshTokenTTL:     conf.Auth.RefreshTokenTTL,
+	})
+	profanityWorker := &backgroundWorker{}
+	apiCfg.workers = map[string]*backgroundWorker{"profanity_reload": profanityWorker}
+	apiCfg.background.Go(func() {
+		profanityWorker.run(func() {
+			apiCfg.profanity.Watch(ctx, conf.Moderation.ProfanityReloadInterval, profanityWorker.report, apiCfg.profanitySources...)
+		})
+	})
+	// Readiness fails from the shutdown signal on, through the drain delay
+	context.AfterFunc(ctx, func() { apiCfg.shuttingDown.Store(true) })
+
+	routes := apiCfg.routes(conf.Server.FileRoot, conf.Metrics.Token)
+
+	// Compression sits inside the metrics, so response sizes are what was sent
+	handler := tracing.Middleware(logging.Middleware(logger, appMetrics.Middleware(compress.Middleware(conf.Server.CompressMinBytes, routes))))
+	server := newServer(":"+strconv.Itoa(conf.Server.Port), handler, conf.Server)
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		fatal("Error listening", err, "addr", server.Addr)
+	}
+
+	logger.Info("Serving files", "path", conf.Server.FileRoot, "port", conf.Server.Port)
+	err = serve(ctx, server, ln, conf.Server)
+	if err != nil {
+		logger.Error("Server stopped", "error", err)
+	}
+
+	// Requests have drained; stop the workers before closing what they use
+	logger.Info("Shutting down")
+	stop()
+	apiCfg.background.Wait()
+	closeCache()
+	if db != nil {
+		db.Close()
+	}
+	shutdownTracing(context.Background())
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// fatal logs msg with err at error level and exits.
+func fatal(msg string, err error, args ...any) {
+	slog.Error(msg, append(args, "error", err)...)
+	os.Exit(1)
 }
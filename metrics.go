@@ -1,8 +1,11 @@
 package main
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
 )
 
 func (cfg *apiConfig) middlewareMetric(next http.Handler) http.Handler {
@@ -26,3 +29,21 @@ func (cfg *apiConfig) numberOfHits(w http.ResponseWriter, r *http.Request) {
 	`, cfg.fileserverHits.Load())
 	w.Write([]byte(numberHitsMessage))
 }
+
+// prometheusMetrics serves /metrics. Scrapers have to send METRICS_TOKEN as a
+// bearer token when one is configured.
+func (cfg *apiConfig) prometheusMetrics(token string) http.Handler {
+	handler := cfg.metrics.Handler()
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, err := auth.GetBearerToken(r.Header)
+		if err != nil || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Metrics token invalid", err)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/migrate"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+
+	"github.com/pressly/goose/v3"
+)
+
+const migrateUsage = `usage: chirpy migrate <command> [flags]
+
+commands:
+  up      apply every pending migration
+  down    roll back the latest migration
+  redo    roll back the latest migration and apply it again
+  status  list migrations and whether they are applied
+`
+
+// runMigrate implements "chirpy migrate". Only the database settings are
+// needed, so the rest of the configuration is not validated.
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	command := args[0]
+
+	fs := flag.NewFlagSet("chirpy migrate "+command, flag.ExitOnError)
+	conf, err := config.Parse(fs, args[1:], os.Getenv)
+	if err != nil {
+		return err
+	}
+	if conf.Database.Driver != "postgres" && conf.Database.Driver != "sqlite" {
+		return fmt.Errorf("migrations only apply to database.driver postgres or sqlite, got %q", conf.Database.Driver)
+	}
+	if conf.Database.URL == "" {
+		return errors.New("database.url (DB_URL) is required")
+	}
+
+	db, provider, err := openMigrations(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var results []*goose.MigrationResult
+	switch command {
+	case "up":
+		results, err = provider.Up(ctx)
+		if err == nil && len(results) == 0 {
+			fmt.Fprintln(out, "No pending migrations")
+		}
+	case "down":
+		var result *goose.MigrationResult
+		result, err = provider.Down(ctx)
+		if result != nil {
+			results = append(results, result)
+		}
+	case "redo":
+		results, err = migrate.Redo(ctx, provider)
+	case "status":
+		return printMigrationStatus(ctx, provider, out)
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", command, migrateUsage)
+	}
+
+	for _, result := range results {
+		fmt.Fprintln(out, result)
+	}
+	return err
+}
+
+// openMigrations connects to the configured database, without migrating it,
+// and returns a goose provider for its driver's migrations.
+func openMigrations(dbCfg config.Database) (*sql.DB, *goose.Provider, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+	if dbCfg.Driver == "sqlite" {
+		db, err = storage.ConnectSQLite(dbCfg.URL)
+	} else {
+		db, err = sql.Open("postgres", dbCfg.URL)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var provider *goose.Provider
+	if dbCfg.Driver == "sqlite" {
+		provider, err = storage.NewSQLiteMigrationProvider(db)
+	} else {
+		provider, err = migrate.NewProvider(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, provider, nil
+}
+
+func printMigrationStatus(ctx context.Context, provider *goose.Provider, out io.Writer) error {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "APPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", appliedAt, status.Source.Path)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMigrateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		dbURL   string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "Test 1: No Command",
+			args:    []string{},
+			wantErr: "usage: chirpy migrate",
+		},
+		{
+			name:    "Test 2: Missing Database URL",
+			args:    []string{"up"},
+			wantErr: "DB_URL",
+		},
+		{
+			name:    "Test 3: Unknown Command",
+			dbURL:   "postgres://chirpy@127.0.0.1:1/chirpy?sslmode=disable",
+			args:    []string{"sideways"},
+			wantErr: `unknown migrate command "sideways"`,
+		},
+		{
+			name:    "Test 4: Memory Driver",
+			driver:  "memory",
+			args:    []string{"up"},
+			wantErr: `only apply to database.driver postgres or sqlite, got "memory"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_DRIVER", tt.driver)
+			t.Setenv("DB_URL", tt.dbURL)
+			t.Setenv("CHIRPY_CONFIG", "")
+			err := runMigrate(context.Background(), tt.args, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got: %v; want error containing: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunMigrateSQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_URL", filepath.Join(t.TempDir(), "chirpy.db"))
+	t.Setenv("CHIRPY_CONFIG", "")
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantOut string
+	}{
+		{
+			name:    "Test 1: Status Before Up",
+			args:    []string{"status"},
+			wantOut: "Pending",
+		},
+		{
+			name:    "Test 2: Up",
+			args:    []string{"up"},
+			wantOut: "001_schema.sql",
+		},
+		{
+			name:    "Test 3: Nothing Pending",
+			args:    []string{"up"},
+			wantOut: "No pending migrations",
+		},
+		{
+			name:    "Test 4: Redo",
+			args:    []string{"redo"},
+			wantOut: "down",
+		},
+	}
+
+	// Cases run in order against one database, so later cases see earlier writes
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := runMigrate(ctx, tt.args, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("got: %q; want output containing: %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
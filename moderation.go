@@ -0,0 +1,246 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/service"
+)
+
+type ReportQueueEntry struct {
+	ChirpID         uuid.UUID `json:"chirp_id"`
+	Body            string    `json:"body"`
+	AuthorID        uuid.UUID `json:"author_id"`
+	Hidden          bool      `json:"hidden"`
+	ReportCount     int64     `json:"report_count"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ModeratorID uuid.UUID `json:"moderator_id"`
+	ChirpID     uuid.UUID `json:"chirp_id"`
+	AuthorID    uuid.UUID `json:"author_id"`
+	Action      string    `json:"action"`
+	Note        string    `json:"note"`
+}
+
+func (cfg *apiConfig) getReportQueue(w http.ResponseWriter, r *http.Request) {
+	queue, err := cfg.db.GetOpenReportQueue(r.Context())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading reports from DB", err)
+		return
+	}
+
+	returnedQueue := []ReportQueueEntry{}
+	for _, q := range queue {
+		returnedQueue = append(returnedQueue, ReportQueueEntry{
+			ChirpID:         q.ChirpID,
+			Body:            q.Body,
+			AuthorID:        q.AuthorID,
+			Hidden:          q.HiddenAt.Valid,
+			ReportCount:     q.ReportCount,
+			Reasons:         q.Reasons,
+			FirstReportedAt: q.FirstReportedAt,
+			LastReportedAt:  q.LastReportedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedQueue)
+}
+
+func (cfg *apiConfig) moderateChirp(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	type moderationInput struct {
+		Action        string `json:"action"`
+		Note          string `json:"note"`
+		DurationHours int    `json:"duration_hours"`
+	}
+	in := moderationInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	action, err := cfg.service.ModerateChirp(r.Context(), principal, chirpID, in.Action, in.Note, time.Duration(in.DurationHours)*time.Hour)
+	validationErr := service.ValidationError{}
+	switch {
+	case errors.As(err, &validationErr):
+		respondWithError(w, r, http.StatusBadRequest, validationErr.Error(), nil)
+		return
+	case errors.Is(err, service.ErrNotFound):
+		respondWithError(w, r, http.StatusNotFound, "Chirp of that ID was not found in Database", err)
+		return
+	case errors.Is(err, service.ErrForbidden):
+		respondWithError(w, r, http.StatusForbidden, "Only admins can moderate moderators", nil)
+		return
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "Moderation action failed to write to database", err)
+		return
+	}
+
+	diff := auditDiff{
+		"action": {To: in.Action},
+		"author": {To: action.AuthorID},
+	}
+	if in.Note != "" {
+		diff["note"] = auditChange{To: in.Note}
+	}
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &principal.UserID,
+		Action:     auditChirpModerated,
+		TargetType: auditTargetChirp,
+		TargetID:   chirpID.String(),
+		Diff:       diff,
+	})
+
+	returnedAction := ModerationAction{
+		ID:          action.ID,
+		CreatedAt:   action.CreatedAt,
+		ModeratorID: action.ModeratorID,
+		ChirpID:     action.ChirpID,
+		AuthorID:    action.AuthorID,
+		Action:      action.Action,
+		Note:        action.Note,
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedAction)
+}
+
+// checkCanModerateUser keeps moderators from acting on their peers or admins.
+func (cfg *apiConfig) checkCanModerateUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	principal, _ := requestPrincipal(r)
+
+	err := cfg.service.CanModerateUser(r.Context(), principal, userID)
+	if errors.Is(err, service.ErrNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Error user could not be found in database", err)
+		return false
+	}
+	if errors.Is(err, service.ErrForbidden) {
+		respondWithError(w, r, http.StatusForbidden, "Only admins can moderate moderators", nil)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading user from DB", err)
+		return false
+	}
+	return true
+}
+
+func (cfg *apiConfig) setUserSuspension(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	type suspensionInput struct {
+		// DurationHours of 0 lifts an existing suspension
+		DurationHours int `json:"duration_hours"`
+	}
+	in := suspensionInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if in.DurationHours < 0 {
+		respondWithError(w, r, http.StatusBadRequest, "duration_hours cannot be negative", nil)
+		return
+	}
+
+	if !cfg.checkCanModerateUser(w, r, userID) {
+		return
+	}
+
+	suspendedUntil := sql.NullTime{}
+	if in.DurationHours > 0 {
+		suspendedUntil = sql.NullTime{
+			Time:  time.Now().Add(time.Duration(in.DurationHours) * time.Hour),
+			Valid: true,
+		}
+	}
+	err = cfg.db.SuspendUser(r.Context(), database.SuspendUserParams{
+		ID:             userID,
+		SuspendedUntil: suspendedUntil,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
+		return
+	}
+
+	principal, _ := requestPrincipal(r)
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &principal.UserID,
+		Action:     auditUserSuspensionChanged,
+		TargetType: auditTargetUser,
+		TargetID:   userID.String(),
+		Diff:       auditDiff{"suspended_until": {To: nullTimePtr(suspendedUntil)}},
+	})
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) setUserShadowban(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	type shadowbanInput struct {
+		Shadowbanned bool `json:"shadowbanned"`
+	}
+	in := shadowbanInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if !cfg.checkCanModerateUser(w, r, userID) {
+		return
+	}
+
+	err = cfg.db.SetUserShadowbanned(r.Context(), database.SetUserShadowbannedParams{
+		ID:           userID,
+		Shadowbanned: in.Shadowbanned,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
+		return
+	}
+
+	principal, _ := requestPrincipal(r)
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &principal.UserID,
+		Action:     auditUserShadowbanChanged,
+		TargetType: auditTargetUser,
+		TargetID:   userID.String(),
+		Diff:       auditDiff{"shadowbanned": {To: in.Shadowbanned}},
+	})
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
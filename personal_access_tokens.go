@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+)
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Token      string     `json:"token,omitempty"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toPersonalAccessToken(pat database.PersonalAccessToken) PersonalAccessToken {
+	return PersonalAccessToken{
+		ID:         pat.ID,
+		CreatedAt:  pat.CreatedAt.Time,
+		Name:       pat.Name,
+		Scopes:     pat.Scopes,
+		ExpiresAt:  nullTimePtr(pat.ExpiresAt),
+		LastUsedAt: nullTimePtr(pat.LastUsedAt),
+	}
+}
+
+func (cfg *apiConfig) createPersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
+
+	type createTokenInput struct {
+		Name             string   `json:"name"`
+		Scopes           []string `json:"scopes"`
+		ExpiresInSeconds int      `json:"expires_in_seconds"`
+	}
+	t := createTokenInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&t)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if t.Name == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Token name is required", nil)
+		return
+	}
+	if t.ExpiresInSeconds < 0 {
+		respondWithError(w, r, http.StatusBadRequest, "expires_in_seconds cannot be negative", nil)
+		return
+	}
+	scopes, err := auth.ParseScopes(t.Scopes)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Tokens without an expiry live until they are revoked
+	expiresAt := sql.NullTime{}
+	if t.ExpiresInSeconds > 0 {
+		expiresAt = sql.NullTime{
+			Time:  time.Now().Add(time.Duration(t.ExpiresInSeconds) * time.Second),
+			Valid: true,
+		}
+	}
+
+	token, prefix, hash, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to generate token", err)
+		return
+	}
+
+	scopeStrings := []string{}
+	for _, s := range scopes {
+		scopeStrings = append(scopeStrings, string(s))
+	}
+
+	pat, err := cfg.db.CreatePersonalAccessToken(r.Context(), database.CreatePersonalAccessTokenParams{
+		Name:        t.Name,
+		TokenPrefix: prefix,
+		TokenHash:   hash,
+		Scopes:      scopeStrings,
+		ExpiresAt:   expiresAt,
+		UserID:      userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
+		return
+	}
+
+	// The raw token is only ever returned here, it cannot be recovered later
+	returnedToken := toPersonalAccessToken(pat)
+	returnedToken.Token = token
+
+	respondWithJSON(w, http.StatusCreated, returnedToken)
+}
+
+func (cfg *apiConfig) listPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
+
+	pats, err := cfg.db.GetPersonalAccessTokensByUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading tokens from DB", err)
+		return
+	}
+
+	returnedTokens := []PersonalAccessToken{}
+	for _, pat := range pats {
+		returnedTokens = append(returnedTokens, toPersonalAccessToken(pat))
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedTokens)
+}
+
+func (cfg *apiConfig) revokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
+
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	revoked, err := cfg.db.RevokePersonalAccessToken(r.Context(), database.RevokePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Token not found", nil)
+		return
+	}
+
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &userID,
+		Action:     auditPersonalAccessTokenRevoked,
+		TargetType: auditTargetPersonalAccessToken,
+		TargetID:   tokenID.String(),
+	})
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
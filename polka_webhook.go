@@ -2,10 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/service"
 )
 
 type polkaWebHookRequest struct {
@@ -15,14 +17,26 @@ type polkaWebHookRequest struct {
 	} `json:"data"`
 }
 
+const polkaUserUpgraded = "user.upgraded"
+
+// polkaEventLabel keeps unknown event names out of the metric labels.
+func polkaEventLabel(event string) string {
+	if event == polkaUserUpgraded {
+		return event
+	}
+	return "other"
+}
+
 func (cfg *apiConfig) upgradeToChirpyRed(w http.ResponseWriter, r *http.Request) {
 	requestApiKey, err := auth.GetApiKey(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: API Key invalid", err)
+		cfg.metrics.Webhooks.WithLabelValues("unknown", "unauthorized").Inc()
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: API Key invalid", err)
 		return
 	}
 	if requestApiKey != cfg.polkaApiKey {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: API Key invalid", err)
+		cfg.metrics.Webhooks.WithLabelValues("unknown", "unauthorized").Inc()
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: API Key invalid", err)
 		return
 	}
 
@@ -31,26 +45,47 @@ func (cfg *apiConfig) upgradeToChirpyRed(w http.ResponseWriter, r *http.Request)
 
 	err = decoder.Decode(&p)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		cfg.metrics.Webhooks.WithLabelValues("unknown", "failed").Inc()
+		respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
+	event := polkaEventLabel(p.Event)
 
-	if p.Event != "user.upgraded" {
+	if p.Event != polkaUserUpgraded {
+		cfg.metrics.Webhooks.WithLabelValues(event, "ignored").Inc()
 		respondWithJSON(w, http.StatusNoContent, nil)
 		return
 	}
 
 	userID, err := uuid.Parse(p.Data.User_ID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error parsing given user ID", err)
+		cfg.metrics.Webhooks.WithLabelValues(event, "failed").Inc()
+		respondWithError(w, r, http.StatusInternalServerError, "Error parsing given user ID", err)
+		return
+	}
+	upgraded, err := cfg.service.UpgradeToChirpyRed(r.Context(), userID)
+	if errors.Is(err, service.ErrNotFound) {
+		cfg.metrics.Webhooks.WithLabelValues(event, "failed").Inc()
+		respondWithError(w, r, http.StatusNotFound, "Error user could not be found when writing to database", err)
 		return
 	}
-	// We don't want write back the updated user, so ignoring the response
-	_, err = cfg.db.UpgradeToChirpyRed(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "Error user could not be found when writing to database", err)
+		cfg.metrics.Webhooks.WithLabelValues(event, "failed").Inc()
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
 		return
 	}
 
+	// Webhooks have no actor; the request's IP identifies the caller.
+	// Redelivered events change nothing and are not audited again.
+	if upgraded {
+		cfg.recordAudit(r, auditEvent{
+			Action:     auditUserUpgraded,
+			TargetType: auditTargetUser,
+			TargetID:   userID.String(),
+			Diff:       auditDiff{"is_chirpy_red": {From: false, To: true}},
+		})
+	}
+	cfg.metrics.Webhooks.WithLabelValues(event, "processed").Inc()
+
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
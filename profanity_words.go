@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+)
+
+type ProfanityWord struct {
+	Locale    string    `json:"locale"`
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// profanityDatabaseSource is the last layer of word lists, so words managed
+// through the admin API override the built-in list and PROFANITY_DIR files.
+func (cfg *apiConfig) profanityDatabaseSource(ctx context.Context) ([]profanity.Word, error) {
+	rows, err := cfg.db.ListProfanityWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	words := []profanity.Word{}
+	for _, row := range rows {
+		words = append(words, profanity.Word{
+			Locale: row.Locale,
+			Word:   row.Word,
+			Action: profanity.Action(row.Action),
+		})
+	}
+	return words, nil
+}
+
+func (cfg *apiConfig) reloadProfanity(ctx context.Context) error {
+	return cfg.profanity.Reload(ctx, cfg.profanitySources...)
+}
+
+func (cfg *apiConfig) listProfanityWords(w http.ResponseWriter, r *http.Request) {
+	locale := r.URL.Query().Get("locale")
+
+	rows, err := cfg.db.ListProfanityWords(r.Context())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading profanity words from DB", err)
+		return
+	}
+
+	words := []ProfanityWord{}
+	for _, row := range rows {
+		if locale != "" && row.Locale != locale {
+			continue
+		}
+		words = append(words, ProfanityWord{
+			Locale:    row.Locale,
+			Word:      row.Word,
+			Action:    row.Action,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, words)
+}
+
+func (cfg *apiConfig) upsertProfanityWord(w http.ResponseWriter, r *http.Request) {
+	type wordInput struct {
+		Locale string `json:"locale"`
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+	in := wordInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err := decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	in.Locale = strings.ToLower(strings.TrimSpace(in.Locale))
+	in.Word = strings.TrimSpace(in.Word)
+	if in.Locale == "" || in.Word == "" || strings.ContainsFunc(in.Word, func(r rune) bool { return r == ' ' || r == '/' }) {
+		respondWithError(w, r, http.StatusBadRequest, "A locale and a single word are required", nil)
+		return
+	}
+	action, err := profanity.ParseAction(in.Action)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid action: accepts only mask, flag or reject", err)
+		return
+	}
+
+	row, err := cfg.db.UpsertProfanityWord(r.Context(), database.UpsertProfanityWordParams{
+		Locale: in.Locale,
+		Word:   in.Word,
+		Action: string(action),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Profanity word failed to write to database", err)
+		return
+	}
+
+	err = cfg.reloadProfanity(r.Context())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error reloading profanity lists", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ProfanityWord{
+		Locale:    row.Locale,
+		Word:      row.Word,
+		Action:    row.Action,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) deleteProfanityWord(w http.ResponseWriter, r *http.Request) {
+	deleted, err := cfg.db.DeleteProfanityWord(r.Context(), database.DeleteProfanityWordParams{
+		Locale: r.PathValue("locale"),
+		Word:   r.PathValue("word"),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Profanity word failed to delete from database", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, r, http.StatusNotFound, "Profanity word was not found in Database", nil)
+		return
+	}
+
+	err = cfg.reloadProfanity(r.Context())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error reloading profanity lists", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
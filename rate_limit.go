@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+	"github.com/mattnickolaus/chirpy/internal/ratelimit"
+)
+
+// rateLimitPolicies are the policies applied to the rate limited routes.
+type rateLimitPolicies struct {
+	createChirp ratelimit.Policy
+	signup      ratelimit.Policy
+	// login caps requests per address on top of the failed attempt backoff
+	// in the lockout package, which only counts failures.
+	login ratelimit.Policy
+}
+
+func newRateLimitPolicies(limits config.RateLimit) rateLimitPolicies {
+	return rateLimitPolicies{
+		createChirp: ratelimit.Policy{
+			Name:      "chirps",
+			Default:   limits.Chirps,
+			ChirpyRed: limits.ChirpsChirpyRed,
+		},
+		signup: ratelimit.Policy{
+			Name:    "signup",
+			Default: limits.Signup,
+		},
+		login: ratelimit.Policy{
+			Name:    "login",
+			Default: limits.Login,
+		},
+	}
+}
+
+// rateLimit applies policy to next. Authenticated callers are limited per
+// user, so it must run after authentication; everyone else is limited per
+// client IP. If the store fails, requests are let through rather than taking
+// the API down with it.
+func (cfg *apiConfig) rateLimit(policy ratelimit.Policy, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := policy.Name + ":ip:" + cfg.clientIP(r)
+		chirpyRed := false
+		if principal, ok := requestPrincipal(r); ok {
+			key = policy.Name + ":user:" + principal.UserID.String()
+			chirpyRed = principal.Tier == auth.TierChirpyRed
+		}
+
+		decision, err := cfg.rateLimiter.Allow(r.Context(), key, policy.LimitFor(chirpyRed))
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Error checking rate limit", "policy", policy.Name, "error", err)
+			next(w, r)
+			return
+		}
+
+		setRateLimitHeaders(w, decision)
+		if !decision.Allowed {
+			cfg.metrics.RateLimited.WithLabelValues(policy.Name).Inc()
+			respondWithTooManyRequests(w, r, decision.RetryAfter, "Rate limit exceeded, try again later")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers of the IETF httpapi
+// ratelimit-headers draft.
+func setRateLimitHeaders(w http.ResponseWriter, decision ratelimit.Decision) {
+	limit := decision.Limit
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(decision.Reset.Seconds()))))
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(int(limit.Per.Seconds())))
+}
@@ -1,11 +1,157 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 )
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(http.StatusText(http.StatusOK)))
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	checkOK = "ok"
+	// checkDegraded is reported but does not fail the probe
+	checkDegraded = "degraded"
+	checkFail     = "fail"
+)
+
+type healthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// backgroundWorker tracks a long-running goroutine for the health checks.
+type backgroundWorker struct {
+	mu      sync.Mutex
+	running bool
+	lastErr error
+}
+
+// run calls fn and marks the worker as running until fn returns.
+func (bw *backgroundWorker) run(fn func()) {
+	bw.mu.Lock()
+	bw.running = true
+	bw.mu.Unlock()
+
+	defer func() {
+		bw.mu.Lock()
+		bw.running = false
+		bw.mu.Unlock()
+	}()
+	fn()
+}
+
+// report records the outcome of the worker's latest run.
+func (bw *backgroundWorker) report(err error) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	bw.lastErr = err
+}
+
+func (bw *backgroundWorker) check() healthCheck {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	if !bw.running {
+		return healthCheck{Status: checkFail, Error: "not running"}
+	}
+	if bw.lastErr != nil {
+		return healthCheck{Status: checkDegraded, Error: bw.lastErr.Error()}
+	}
+	return healthCheck{Status: checkOK}
+}
+
+// liveness fails only when the process needs a restart: a background worker
+// has stopped.
+func (cfg *apiConfig) liveness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]healthCheck{}
+	for name, worker := range cfg.workers {
+		checks[name] = worker.check()
+	}
+	respondWithHealth(w, checks)
+}
+
+// readiness fails while the instance cannot serve traffic: the database is
+// unreachable or not migrated, or the server is shutting down. The in-memory
+// store has no database to check.
+func (cfg *apiConfig) readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]healthCheck{
+		"shutdown": {Status: checkOK},
+	}
+	if cfg.sqlDB != nil {
+		checks["database"] = cfg.checkDatabase(ctx)
+	}
+	if cfg.schemaVersion > 0 {
+		checks["migrations"] = cfg.checkMigrations(ctx)
+	}
+	if cfg.shuttingDown.Load() {
+		checks["shutdown"] = healthCheck{Status: checkFail, Error: "shutting down"}
+	}
+	for name, worker := range cfg.workers {
+		checks[name] = worker.check()
+	}
+	respondWithHealth(w, checks)
+}
+
+func (cfg *apiConfig) checkDatabase(ctx context.Context) healthCheck {
+	err := cfg.sqlDB.PingContext(ctx)
+	if err != nil {
+		return healthCheck{Status: checkFail, Error: err.Error()}
+	}
+	return healthCheck{Status: checkOK}
+}
+
+// checkMigrations reads goose's version table directly rather than through a
+// goose provider, which would wait behind a running migration. A database
+// ahead of the binary is only degraded: during a rolling deploy the old
+// replicas keep serving after the new ones have migrated.
+func (cfg *apiConfig) checkMigrations(ctx context.Context) healthCheck {
+	var version int64
+	err := cfg.sqlDB.QueryRowContext(ctx, "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied").Scan(&version)
+	if err != nil {
+		return healthCheck{Status: checkFail, Error: err.Error()}
+	}
+	switch {
+	case version < cfg.schemaVersion:
+		return healthCheck{
+			Status: checkFail,
+			Error:  fmt.Sprintf("database is at migration %d, expected %d", version, cfg.schemaVersion),
+		}
+	case version > cfg.schemaVersion:
+		return healthCheck{
+			Status: checkDegraded,
+			Error:  fmt.Sprintf("database is at migration %d, newer than %d", version, cfg.schemaVersion),
+		}
+	}
+	return healthCheck{Status: checkOK}
+}
+
+// respondWithHealth reports 503 when any check failed.
+func respondWithHealth(w http.ResponseWriter, checks map[string]healthCheck) {
+	report := healthReport{Status: checkOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status == checkFail {
+			report.Status = checkFail
+			break
+		}
+		if check.Status == checkDegraded {
+			report.Status = checkDegraded
+		}
+	}
+
+	code := http.StatusOK
+	if report.Status == checkFail {
+		code = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, code, report)
 }
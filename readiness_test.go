@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattnickolaus/chirpy/internal/storage"
+)
+
+func TestLiveness(t *testing.T) {
+	tests := []struct {
+		name       string
+		running    bool
+		lastErr    error
+		wantCode   int
+		wantStatus string
+	}{
+		{
+			name:       "Test 1: Worker Running",
+			running:    true,
+			wantCode:   http.StatusOK,
+			wantStatus: checkOK,
+		},
+		{
+			name:       "Test 2: Last Run Failed",
+			running:    true,
+			lastErr:    errors.New("directory missing"),
+			wantCode:   http.StatusOK,
+			wantStatus: checkDegraded,
+		},
+		{
+			name:       "Test 3: Worker Stopped",
+			running:    false,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: checkFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker := &backgroundWorker{running: tt.running, lastErr: tt.lastErr}
+			cfg := apiConfig{workers: map[string]*backgroundWorker{"profanity_reload": worker}}
+
+			rec := httptest.NewRecorder()
+			cfg.liveness(rec, httptest.NewRequest("GET", "/api/healthz/live", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got: %v; want: %v", rec.Code, tt.wantCode)
+			}
+			report := healthReport{}
+			json.NewDecoder(rec.Body).Decode(&report)
+			if report.Checks["profanity_reload"].Status != tt.wantStatus {
+				t.Errorf("got: %v; want: %v", report.Checks["profanity_reload"].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	// Nothing listens on port 1, so every database check fails
+	db, err := sql.Open("postgres", "postgres://chirpy@127.0.0.1:1/chirpy?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cfg := apiConfig{sqlDB: db, schemaVersion: 1}
+	cfg.shuttingDown.Store(true)
+
+	rec := httptest.NewRecorder()
+	cfg.readiness(rec, httptest.NewRequest("GET", "/api/healthz/ready", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got: %v; want: %v", rec.Code, http.StatusServiceUnavailable)
+	}
+	report := healthReport{}
+	json.NewDecoder(rec.Body).Decode(&report)
+	for _, name := range []string{"shutdown", "database", "migrations"} {
+		if report.Checks[name].Status != checkFail {
+			t.Errorf("%s: got: %v; want: %v", name, report.Checks[name].Status, checkFail)
+		}
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := storage.OpenSQLite(ctx, filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int64
+	err = db.QueryRowContext(ctx, "SELECT MAX(version_id) FROM goose_db_version").Scan(&version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		schemaVersion int64
+		wantStatus    string
+	}{
+		{
+			name:          "Test 1: Up To Date",
+			schemaVersion: version,
+			wantStatus:    checkOK,
+		},
+		{
+			name:          "Test 2: Database Behind",
+			schemaVersion: version + 1,
+			wantStatus:    checkFail,
+		},
+		{
+			name:          "Test 3: Database Ahead",
+			schemaVersion: version - 1,
+			wantStatus:    checkDegraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := apiConfig{sqlDB: db, schemaVersion: tt.schemaVersion}
+			check := cfg.checkMigrations(ctx)
+			if check.Status != tt.wantStatus {
+				t.Errorf("got: %v; want: %v", check.Status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -1,42 +1,70 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"time"
 
 	"github.com/mattnickolaus/chirpy/internal/auth"
 	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/service"
 )
 
+// lookupRefreshToken finds the stored record for a raw refresh token. Rows are
+// narrowed by prefix and the hash is then compared in constant time.
+func (cfg *apiConfig) lookupRefreshToken(ctx context.Context, refreshTokenString string) (database.RefreshToken, error) {
+	candidates, err := cfg.db.GetRefreshTokensByPrefix(ctx, auth.RefreshTokenPrefix(refreshTokenString))
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+
+	for _, c := range candidates {
+		if auth.CheckTokenHash(refreshTokenString, c.TokenHash) {
+			return c, nil
+		}
+	}
+
+	return database.RefreshToken{}, sql.ErrNoRows
+}
+
 func (cfg *apiConfig) refreshAccessToken(w http.ResponseWriter, r *http.Request) {
 	refreshTokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
 		return
 	}
 
-	refreshTokenRecord, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshTokenString)
+	refreshTokenRecord, err := cfg.lookupRefreshToken(r.Context(), refreshTokenString)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
 		return
 	}
 
 	if refreshTokenRecord.RevokedAt.Valid {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Revoked", nil)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Revoked", nil)
 		return
 	}
 
 	if refreshTokenRecord.ExpiresAt.Time.Before(time.Now()) {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Revoked", nil)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Revoked", nil)
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(r.Context(), refreshTokenRecord.UserID)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		return
+	}
+	if user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(time.Now()) {
+		suspended := service.SuspendedError{Until: user.SuspendedUntil.Time}
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: "+suspended.Error(), nil)
 		return
 	}
 
-	// NOTE: Hard Coded 1 hour JWT expiration time
-	expiresInHour := time.Second * time.Duration(3600)
-	accessTokenString, err := auth.MakeJWT(refreshTokenRecord.UserID, cfg.secret, expiresInHour)
+	accessTokenString, err := auth.MakeJWT(refreshTokenRecord.UserID, cfg.jwtKeys, cfg.accessTokenTTL)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to generate Web Token", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to generate Web Token", err)
 		return
 	}
 
@@ -54,7 +82,13 @@ func (cfg *apiConfig) refreshAccessToken(w http.ResponseWriter, r *http.Request)
 func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refreshTokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		return
+	}
+
+	refreshTokenRecord, err := cfg.lookupRefreshToken(r.Context(), refreshTokenString)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
 		return
 	}
 
@@ -64,16 +98,23 @@ func (cfg *apiConfig) revokeRefreshToken(w http.ResponseWriter, r *http.Request)
 	}
 
 	revokeParams := database.RevokeRefreshTokenParams{
-		Token:     refreshTokenString,
+		ID:        refreshTokenRecord.ID,
 		RevokedAt: nowTime,
 		UpdatedAt: nowTime,
 	}
 
 	err = cfg.db.RevokeRefreshToken(r.Context(), revokeParams)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
+		respondWithError(w, r, http.StatusUnauthorized, "Unauthorized: Refresh Token Invalid", err)
 		return
 	}
 
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &refreshTokenRecord.UserID,
+		Action:     auditRefreshTokenRevoked,
+		TargetType: auditTargetRefreshToken,
+		TargetID:   refreshTokenRecord.ID.String(),
+	})
+
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/service"
+)
+
+type ChirpReport struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details"`
+}
+
+func (cfg *apiConfig) reportChirp(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	type reportInput struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	in := reportInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	report, err := cfg.service.ReportChirp(r.Context(), principal.UserID, chirpID, in.Reason, in.Details)
+	validationErr := service.ValidationError{}
+	switch {
+	case errors.As(err, &validationErr):
+		respondWithError(w, r, http.StatusBadRequest, validationErr.Error(), nil)
+		return
+	case errors.Is(err, service.ErrNotFound):
+		respondWithError(w, r, http.StatusNotFound, "Chirp of that ID was not found in Database", err)
+		return
+	case errors.Is(err, service.ErrAlreadyReported):
+		respondWithError(w, r, http.StatusConflict, "You have already reported this Chirp", nil)
+		return
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "Report failed to write to database", err)
+		return
+	}
+
+	returnedReport := ChirpReport{
+		ID:        report.ID,
+		CreatedAt: report.CreatedAt,
+		ChirpID:   report.ChirpID,
+		Reason:    report.Reason,
+		Details:   report.Details,
+	}
+
+	respondWithJSON(w, http.StatusCreated, returnedReport)
+}
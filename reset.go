@@ -17,6 +17,14 @@ func (cfg *apiConfig) resetHits(w http.ResponseWriter, r *http.Request) {
 	}
 	cfg.db.DeleteAllUsers(r.Context())
 
+	principal, _ := requestPrincipal(r)
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &principal.UserID,
+		Action:     auditAdminReset,
+		TargetType: auditTargetPlatform,
+		TargetID:   cfg.platform,
+	})
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(resetHits))
 }
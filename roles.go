@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) setUserRole(w http.ResponseWriter, r *http.Request) {
+	principal, _ := requestPrincipal(r)
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Unable to parse the uuid from provide path", err)
+		return
+	}
+
+	type setRoleInput struct {
+		Role string `json:"role"`
+	}
+	in := setRoleInput{}
+	decoder := json.NewDecoder(r.Body)
+
+	err = decoder.Decode(&in)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	role, err := auth.ParseRole(in.Role)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Keeps the last admin from locking everyone out by demoting themselves
+	if userID == principal.UserID && role != auth.RoleAdmin {
+		respondWithError(w, r, http.StatusBadRequest, "Admins cannot demote themselves", nil)
+		return
+	}
+
+	currentUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Error user could not be found in database", err)
+		return
+	}
+
+	updatedUser, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: string(role),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "Error user could not be found when writing to database", err)
+		return
+	}
+
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &principal.UserID,
+		Action:     auditUserRoleChanged,
+		TargetType: auditTargetUser,
+		TargetID:   userID.String(),
+		Diff:       auditDiff{"role": {From: currentUser.Role, To: updatedUser.Role}},
+	})
+
+	returnedUser := User{
+		ID:          updatedUser.ID,
+		CreatedAt:   updatedUser.CreatedAt.Time,
+		UpdatedAt:   updatedUser.UpdatedAt.Time,
+		Email:       updatedUser.Email,
+		IsChirpyRed: updatedUser.IsChirpyRed.Bool,
+		Role:        updatedUser.Role,
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedUser)
+}
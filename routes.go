@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/compress"
+	"github.com/mattnickolaus/chirpy/internal/cors"
+	"github.com/mattnickolaus/chirpy/internal/secure"
+)
+
+// routes registers every endpoint behind the CORS and security headers.
+// fileRoot is served under /app/ and metricsToken, when set, guards /metrics.
+func (cfg *apiConfig) routes(fileRoot, metricsToken string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/app/", secure.Headers(cfg.headers.app, http.StripPrefix("/app", cfg.middlewareMetric(compress.FileServer(http.Dir(fileRoot))))))
+
+	mux.HandleFunc("GET /api/healthz/live", cfg.liveness)
+	mux.HandleFunc("GET /api/healthz/ready", cfg.readiness)
+	// Kept for probes configured before the split
+	mux.HandleFunc("GET /api/healthz", cfg.liveness)
+	mux.Handle("GET /metrics", cfg.prometheusMetrics(metricsToken))
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.getJWKS)
+
+	mux.HandleFunc("POST /api/users", cfg.rateLimit(cfg.rateLimits.signup, cfg.createUser))
+	mux.Handle("PUT /api/users", cfg.requireSession(cfg.updateUser))
+	mux.HandleFunc("POST /api/login", cfg.rateLimit(cfg.rateLimits.login, cfg.login))
+
+	mux.Handle("POST /api/tokens", cfg.requireSession(cfg.createPersonalAccessToken))
+	mux.Handle("GET /api/tokens", cfg.requireSession(cfg.listPersonalAccessTokens))
+	mux.Handle("DELETE /api/tokens/{tokenID}", cfg.requireSession(cfg.revokePersonalAccessToken))
+
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.upgradeToChirpyRed)
+
+	mux.Handle("POST /api/chirps", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.rateLimit(cfg.rateLimits.createChirp, cfg.createChirp)))
+	mux.Handle("GET /api/chirps", cfg.optionalAuth(auth.ScopeChirpsRead, cfg.getAllChirps))
+	mux.Handle("GET /api/chirps/{chirpID}", cfg.optionalAuth(auth.ScopeChirpsRead, cfg.getChirp))
+	mux.Handle("DELETE /api/chirps/{chirpID}", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.deleteChirp))
+	mux.Handle("POST /api/chirps/{chirpID}/report", cfg.requireAuth(auth.ScopeChirpsWrite, cfg.reportChirp))
+
+	mux.HandleFunc("POST /api/refresh", cfg.refreshAccessToken)
+	mux.HandleFunc("POST /api/revoke", cfg.revokeRefreshToken)
+
+	mux.Handle("POST /admin/reset", cfg.requireRole(auth.RoleAdmin, cfg.resetHits))
+	mux.Handle("GET /admin/metrics", cfg.requireRole(auth.RoleAdmin, cfg.numberOfHits))
+	mux.Handle("PUT /admin/users/{userID}/role", cfg.requireRole(auth.RoleAdmin, cfg.setUserRole))
+	mux.Handle("GET /admin/audit", cfg.requireRole(auth.RoleAdmin, cfg.listAuditEvents))
+	mux.Handle("GET /admin/profanity", cfg.requireRole(auth.RoleAdmin, cfg.listProfanityWords))
+	mux.Handle("POST /admin/profanity", cfg.requireRole(auth.RoleAdmin, cfg.upsertProfanityWord))
+	mux.Handle("DELETE /admin/profanity/{locale}/{word}", cfg.requireRole(auth.RoleAdmin, cfg.deleteProfanityWord))
+	mux.Handle("GET /admin/reports", cfg.requireRole(auth.RoleModerator, cfg.getReportQueue))
+	mux.Handle("POST /admin/reports/{chirpID}/actions", cfg.requireRole(auth.RoleModerator, cfg.moderateChirp))
+	mux.Handle("GET /admin/spam-decisions", cfg.requireRole(auth.RoleModerator, cfg.listSpamDecisions))
+	mux.Handle("PUT /admin/users/{userID}/suspension", cfg.requireRole(auth.RoleModerator, cfg.setUserSuspension))
+	mux.Handle("PUT /admin/users/{userID}/shadowban", cfg.requireRole(auth.RoleModerator, cfg.setUserShadowban))
+
+	// Refused preflights get the security headers too
+	return secure.Headers(cfg.headers.api, cors.Middleware(cfg.cors, mux))
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/auth"
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/lockout"
+	"github.com/mattnickolaus/chirpy/internal/metrics"
+	"github.com/mattnickolaus/chirpy/internal/profanity"
+	"github.com/mattnickolaus/chirpy/internal/ratelimit"
+	"github.com/mattnickolaus/chirpy/internal/service"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+)
+
+const (
+	testAdminEmail = "admin@example.com"
+	testPolkaKey   = "f271c81ff7084ee5b99a5091b42d486e"
+	testPassword   = "correct horse battery staple"
+	testOrigin     = "https://client.example.com"
+)
+
+// newTestAPI wires the routes to an in-memory store the same way main does,
+// with rate limits loose enough for one test to sign up several users and
+// CORS allowing testOrigin. The testAdminEmail account is created and
+// promoted up front, as "chirpy bootstrap-admin" would.
+func newTestAPI(t *testing.T) http.Handler {
+	t.Helper()
+	limits := config.Default().RateLimit
+	limits.Signup = ratelimit.Limit{Requests: 100, Per: time.Hour}
+	corsConfig := config.Default().CORS
+	corsConfig.AllowedOrigins = []string{testOrigin}
+
+	jwtKeys := auth.NewHMACKeySet("c2VjdXJlLXJhbmRvbS1ieXRlcy1mb3ItdGVzdHMtb25seQ==")
+	// Lookups go through a memory cache, as they do when one is configured
+	appMetrics := metrics.New()
+	cacheConfig := config.Default().Cache
+	cacheConfig.Backend = "memory"
+	store, _, err := cacheStore(storage.NewMemoryStore(), cacheConfig, appMetrics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := profanity.NewFilter(profanity.DefaultWords)
+
+	cfg := &apiConfig{
+		metrics:        appMetrics,
+		db:             store,
+		platform:       "dev",
+		jwtKeys:        jwtKeys,
+		loginGuard:     lockout.NewGuard(lockout.NewMemoryStore(), lockout.DefaultAccountPolicy, lockout.DefaultIPPolicy),
+		rateLimiter:    ratelimit.NewLimiter(ratelimit.NewMemoryStore()),
+		rateLimits:     newRateLimitPolicies(limits),
+		cors:           newCORSOptions(corsConfig),
+		headers:        newHeaderPolicies(config.Default().Security),
+		profanity:      filter,
+		polkaApiKey:    testPolkaKey,
+		accessTokenTTL: time.Hour,
+		workers:        map[string]*backgroundWorker{},
+		service: service.New(store, service.Config{
+			ChirpMaxLength:      140,
+			ReportHideThreshold: 3,
+			Profanity:           filter,
+			Spam:                spam.DefaultConfig,
+			JWTKeys:             jwtKeys,
+			AccessTokenTTL:      time.Hour,
+			RefreshTokenTTL:     time.Hour,
+		}),
+	}
+	cfg.profanitySources = []profanity.Source{profanity.StaticSource(profanity.DefaultWords), cfg.profanityDatabaseSource}
+
+	hashed, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := store.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          testAdminEmail,
+		HashedPassword: sql.NullString{String: hashed, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.service.BootstrapAdmin(context.Background(), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	fileRoot := t.TempDir()
+	err = os.WriteFile(filepath.Join(fileRoot, "index.html"), []byte("<h1>Welcome to Chirpy</h1>"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.routes(fileRoot, "")
+}
+
+// serveJSON sends body as JSON with the given Authorization header and
+// returns the recorded response.
+func serveJSON(t *testing.T, h http.Handler, method, path, authorization string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode fails the test unless rec has wantCode, then decodes its body into v.
+func decode(t *testing.T, rec *httptest.ResponseRecorder, wantCode int, v any) {
+	t.Helper()
+	if rec.Code != wantCode {
+		t.Fatalf("got: %v; want: %v (body: %s)", rec.Code, wantCode, rec.Body)
+	}
+	err := json.NewDecoder(rec.Body).Decode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signup creates an account and logs in, returning the user with its tokens.
+func signup(t *testing.T, h http.Handler, email string) User {
+	t.Helper()
+	credentials := map[string]string{"email": email, "password": testPassword}
+	decode(t, serveJSON(t, h, "POST", "/api/users", "", credentials), http.StatusCreated, &User{})
+	return login(t, h, email)
+}
+
+// login logs in an existing account with testPassword.
+func login(t *testing.T, h http.Handler, email string) User {
+	t.Helper()
+	credentials := map[string]string{"email": email, "password": testPassword}
+	user := User{}
+	decode(t, serveJSON(t, h, "POST", "/api/login", "", credentials), http.StatusOK, &user)
+	return user
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
+
+func TestRoutes(t *testing.T) {
+	h := newTestAPI(t)
+
+	admin := login(t, h, testAdminEmail)
+	moderator := signup(t, h, "moderator@example.com")
+	alice := signup(t, h, "alice@example.com")
+	bob := signup(t, h, "bob@example.com")
+	decode(t, serveJSON(t, h, "PUT", "/admin/users/"+moderator.ID.String()+"/role", bearer(admin.Token), map[string]string{"role": "moderator"}), http.StatusOK, &User{})
+
+	chirp := Chirp{}
+	decode(t, serveJSON(t, h, "POST", "/api/chirps", bearer(alice.Token), map[string]string{"body": "Hello from the route tests"}), http.StatusCreated, &chirp)
+	reported := Chirp{}
+	decode(t, serveJSON(t, h, "POST", "/api/chirps", bearer(bob.Token), map[string]string{"body": "A chirp someone will report"}), http.StatusCreated, &reported)
+
+	pat := PersonalAccessToken{}
+	decode(t, serveJSON(t, h, "POST", "/api/tokens", bearer(alice.Token), map[string]any{"name": "ci", "scopes": []string{"chirps:read"}}), http.StatusCreated, &pat)
+	writePAT := PersonalAccessToken{}
+	decode(t, serveJSON(t, h, "POST", "/api/tokens", bearer(alice.Token), map[string]any{"name": "bot", "scopes": []string{"chirps:write"}}), http.StatusCreated, &writePAT)
+
+	chirpPath := "/api/chirps/" + chirp.ID.String()
+	missingChirpPath := "/api/chirps/00000000-0000-0000-0000-000000000000"
+	reportedPath := "/api/chirps/" + reported.ID.String()
+	bobPath := "/admin/users/" + bob.ID.String()
+
+	// Cases run in order against one store, so later cases see earlier writes
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		body          any
+		wantCode      int
+	}{
+		{name: "Test 1: Static Files", method: "GET", path: "/app/", wantCode: http.StatusOK},
+		{name: "Test 2: Liveness", method: "GET", path: "/api/healthz/live", wantCode: http.StatusOK},
+		{name: "Test 3: Readiness Without Database", method: "GET", path: "/api/healthz/ready", wantCode: http.StatusOK},
+		{name: "Test 4: Legacy Health Check", method: "GET", path: "/api/healthz", wantCode: http.StatusOK},
+		{name: "Test 5: Prometheus Metrics", method: "GET", path: "/metrics", wantCode: http.StatusOK},
+		{name: "Test 6: JWKS", method: "GET", path: "/.well-known/jwks.json", wantCode: http.StatusOK},
+
+		{name: "Test 7: Update User", method: "PUT", path: "/api/users", authorization: bearer(alice.Token), body: map[string]string{"email": "alice@example.com", "password": testPassword}, wantCode: http.StatusOK},
+		{name: "Test 8: Update User Unauthenticated", method: "PUT", path: "/api/users", body: map[string]string{"email": "mallory@example.com", "password": testPassword}, wantCode: http.StatusUnauthorized},
+		{name: "Test 9: Login Wrong Password", method: "POST", path: "/api/login", body: map[string]string{"email": "bob@example.com", "password": "wrong"}, wantCode: http.StatusUnauthorized},
+
+		{name: "Test 10: List Tokens", method: "GET", path: "/api/tokens", authorization: bearer(alice.Token), wantCode: http.StatusOK},
+		{name: "Test 11: Token Cannot Manage Tokens", method: "GET", path: "/api/tokens", authorization: bearer(pat.Token), wantCode: http.StatusForbidden},
+		{name: "Test 12: Token Reads Chirps", method: "GET", path: chirpPath, authorization: bearer(pat.Token), wantCode: http.StatusOK},
+		{name: "Test 13: Token Without Write Scope", method: "POST", path: "/api/chirps", authorization: bearer(pat.Token), body: map[string]string{"body": "Not allowed"}, wantCode: http.StatusForbidden},
+		{name: "Test 14: Token Without Read Scope", method: "GET", path: "/api/chirps", authorization: bearer(writePAT.Token), wantCode: http.StatusForbidden},
+		{name: "Test 15: Token Cannot Update User", method: "PUT", path: "/api/users", authorization: bearer(writePAT.Token), body: map[string]string{"email": "mallory@example.com", "password": testPassword}, wantCode: http.StatusForbidden},
+		{name: "Test 16: Negative Token Expiry", method: "POST", path: "/api/tokens", authorization: bearer(alice.Token), body: map[string]any{"name": "ci", "scopes": []string{"chirps:read"}, "expires_in_seconds": -1}, wantCode: http.StatusBadRequest},
+		{name: "Test 17: Revoke Token", method: "DELETE", path: "/api/tokens/" + pat.ID.String(), authorization: bearer(alice.Token), wantCode: http.StatusNoContent},
+
+		{name: "Test 18: Polka Bad Key", method: "POST", path: "/api/polka/webhooks", authorization: "ApiKey wrong", body: map[string]any{"event": "user.upgraded", "data": map[string]string{"user_id": alice.ID.String()}}, wantCode: http.StatusUnauthorized},
+		{name: "Test 19: Polka Ignored Event", method: "POST", path: "/api/polka/webhooks", authorization: "ApiKey " + testPolkaKey, body: map[string]any{"event": "user.payment_failed", "data": map[string]string{"user_id": alice.ID.String()}}, wantCode: http.StatusNoContent},
+		{name: "Test 20: Polka Upgrade", method: "POST", path: "/api/polka/webhooks", authorization: "ApiKey " + testPolkaKey, body: map[string]any{"event": "user.upgraded", "data": map[string]string{"user_id": alice.ID.String()}}, wantCode: http.StatusNoContent},
+
+		{name: "Test 21: Chirp Too Long", method: "POST", path: "/api/chirps", authorization: bearer(alice.Token), body: map[string]string{"body": string(bytes.Repeat([]byte("a"), 141))}, wantCode: http.StatusBadRequest},
+		{name: "Test 22: List Chirps", method: "GET", path: "/api/chirps", wantCode: http.StatusOK},
+		{name: "Test 23: Get Chirp", method: "GET", path: chirpPath, wantCode: http.StatusOK},
+		{name: "Test 24: Get Missing Chirp", method: "GET", path: missingChirpPath, wantCode: http.StatusNotFound},
+		{name: "Test 25: Report Chirp", method: "POST", path: reportedPath + "/report", authorization: bearer(alice.Token), body: map[string]string{"reason": "spam"}, wantCode: http.StatusCreated},
+		{name: "Test 26: Report Chirp Twice", method: "POST", path: reportedPath + "/report", authorization: bearer(alice.Token), body: map[string]string{"reason": "spam"}, wantCode: http.StatusConflict},
+		{name: "Test 27: Delete Chirp Not Owner", method: "DELETE", path: chirpPath, authorization: bearer(bob.Token), wantCode: http.StatusForbidden},
+		{name: "Test 28: Delete Chirp", method: "DELETE", path: chirpPath, authorization: bearer(alice.Token), wantCode: http.StatusNoContent},
+		{name: "Test 29: Get Deleted Chirp", method: "GET", path: chirpPath, wantCode: http.StatusNotFound},
+
+		{name: "Test 30: Refresh", method: "POST", path: "/api/refresh", authorization: bearer(bob.RefreshToken), wantCode: http.StatusOK},
+		{name: "Test 31: Revoke", method: "POST", path: "/api/revoke", authorization: bearer(bob.RefreshToken), wantCode: http.StatusNoContent},
+		{name: "Test 32: Refresh Revoked", method: "POST", path: "/api/refresh", authorization: bearer(bob.RefreshToken), wantCode: http.StatusUnauthorized},
+
+		{name: "Test 33: Admin Metrics", method: "GET", path: "/admin/metrics", authorization: bearer(admin.Token), wantCode: http.StatusOK},
+		{name: "Test 34: Admin Metrics Not Admin", method: "GET", path: "/admin/metrics", authorization: bearer(moderator.Token), wantCode: http.StatusForbidden},
+		{name: "Test 35: Audit Log", method: "GET", path: "/admin/audit?action=user.role_changed", authorization: bearer(admin.Token), wantCode: http.StatusOK},
+		{name: "Test 36: Audit Log Bad Limit", method: "GET", path: "/admin/audit?limit=0", authorization: bearer(admin.Token), wantCode: http.StatusBadRequest},
+		{name: "Test 37: Add Profanity Word", method: "POST", path: "/admin/profanity", authorization: bearer(admin.Token), body: map[string]string{"locale": "en", "word": "snollygoster", "action": "reject"}, wantCode: http.StatusOK},
+		{name: "Test 38: Rejected Word In Unknown Locale", method: "POST", path: "/api/chirps", authorization: bearer(alice.Token), body: map[string]string{"body": "Snollygoster!", "locale": "zz"}, wantCode: http.StatusBadRequest},
+		{name: "Test 39: List Profanity Words", method: "GET", path: "/admin/profanity", authorization: bearer(admin.Token), wantCode: http.StatusOK},
+		{name: "Test 40: Delete Profanity Word", method: "DELETE", path: "/admin/profanity/en/snollygoster", authorization: bearer(admin.Token), wantCode: http.StatusNoContent},
+		{name: "Test 41: Delete Missing Profanity Word", method: "DELETE", path: "/admin/profanity/en/snollygoster", authorization: bearer(admin.Token), wantCode: http.StatusNotFound},
+
+		{name: "Test 42: Report Queue", method: "GET", path: "/admin/reports", authorization: bearer(moderator.Token), wantCode: http.StatusOK},
+		{name: "Test 43: Report Queue Not Moderator", method: "GET", path: "/admin/reports", authorization: bearer(alice.Token), wantCode: http.StatusForbidden},
+		{name: "Test 44: Dismiss Reports", method: "POST", path: "/admin/reports/" + reported.ID.String() + "/actions", authorization: bearer(moderator.Token), body: map[string]string{"action": "dismiss"}, wantCode: http.StatusOK},
+		{name: "Test 45: Spam Decisions", method: "GET", path: "/admin/spam-decisions", authorization: bearer(moderator.Token), wantCode: http.StatusOK},
+		{name: "Test 46: Shadowban", method: "PUT", path: bobPath + "/shadowban", authorization: bearer(moderator.Token), body: map[string]bool{"shadowbanned": true}, wantCode: http.StatusNoContent},
+		{name: "Test 47: Shadowbanned Chirp Hidden", method: "GET", path: reportedPath, wantCode: http.StatusNotFound},
+		{name: "Test 48: Suspend", method: "PUT", path: bobPath + "/suspension", authorization: bearer(moderator.Token), body: map[string]int{"duration_hours": 1}, wantCode: http.StatusNoContent},
+		{name: "Test 49: Suspended User Cannot Chirp", method: "POST", path: "/api/chirps", authorization: bearer(bob.Token), body: map[string]string{"body": "Still here"}, wantCode: http.StatusForbidden},
+
+		{name: "Test 50: Reset", method: "POST", path: "/admin/reset", authorization: bearer(admin.Token), wantCode: http.StatusOK},
+		{name: "Test 51: Login After Reset", method: "POST", path: "/api/login", body: map[string]string{"email": "alice@example.com", "password": testPassword}, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(t, h, tt.method, tt.path, tt.authorization, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Errorf("got: %v; want: %v (body: %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestLoginSuspended(t *testing.T) {
+	h := newTestAPI(t)
+	admin := login(t, h, testAdminEmail)
+	bob := signup(t, h, "bob@example.com")
+
+	rec := serveJSON(t, h, "PUT", "/admin/users/"+bob.ID.String()+"/suspension", bearer(admin.Token), map[string]int{"duration_hours": 1})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("got: %v; want: %v (body: %s)", rec.Code, http.StatusNoContent, rec.Body)
+	}
+	rec = serveJSON(t, h, "POST", "/api/login", "", map[string]string{"email": "bob@example.com", "password": testPassword})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("got: %v; want: %v (body: %s)", rec.Code, http.StatusForbidden, rec.Body)
+	}
+
+	events := []AuditEvent{}
+	decode(t, serveJSON(t, h, "GET", "/admin/audit?target_id="+bob.ID.String(), bearer(admin.Token), nil), http.StatusOK, &events)
+	actions := []string{}
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	// Newest first: the refused login, the suspension, then the signup login
+	want := []string{auditLoginRefused, auditUserSuspensionChanged, auditLoginSucceeded}
+	if !slices.Equal(actions, want) {
+		t.Errorf("got: %v; want: %v", actions, want)
+	}
+
+	// Only the admin's and bob's earlier logins succeeded
+	rec = serveJSON(t, h, "GET", "/metrics", "", nil)
+	for _, line := range []string{`chirpy_logins_total{result="success"} 2`, `chirpy_logins_total{result="suspended"} 1`} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+}
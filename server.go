@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/config"
+)
+
+func newServer(addr string, handler http.Handler, serverCfg config.Server) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           limitBody(serverCfg.MaxBodyBytes, handler),
+		ReadHeaderTimeout: serverCfg.ReadHeaderTimeout,
+		ReadTimeout:       serverCfg.ReadTimeout,
+		WriteTimeout:      serverCfg.WriteTimeout,
+		IdleTimeout:       serverCfg.IdleTimeout,
+		MaxHeaderBytes:    serverCfg.MaxHeaderBytes,
+	}
+}
+
+// limitBody caps request bodies at maxBytes. Reading past the limit fails
+// with an *http.MaxBytesError, which respondWithError turns into a 413.
+func limitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serve runs server on ln until ctx is cancelled. It then keeps serving for
+// the drain delay, stops accepting connections and waits up to the shutdown
+// timeout for in-flight requests.
+func serve(ctx context.Context, server *http.Server, ln net.Listener, serverCfg config.Server) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-time.After(serverCfg.DrainDelay):
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverCfg.ShutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		// Cut off whatever is still running once the deadline passes
+		server.Close()
+		return fmt.Errorf("shutting down: %w", err)
+	}
+
+	err = <-serveErr
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattnickolaus/chirpy/internal/config"
+)
+
+func TestServeShutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		releaseAfter    time.Duration
+		wantErr         bool
+	}{
+		{
+			name:            "Test 1: In-Flight Request Completes",
+			shutdownTimeout: 5 * time.Second,
+			releaseAfter:    100 * time.Millisecond,
+		},
+		{
+			name:            "Test 2: Deadline Passes",
+			shutdownTimeout: 50 * time.Millisecond,
+			releaseAfter:    time.Second,
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			release := make(chan struct{})
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+				w.Write([]byte("done"))
+			})
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			serverCfg := config.Default().Server
+			serverCfg.ShutdownTimeout = tt.shutdownTimeout
+			server := newServer(ln.Addr().String(), handler, serverCfg)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			serveErr := make(chan error, 1)
+			go func() {
+				serveErr <- serve(ctx, server, ln, serverCfg)
+			}()
+
+			type result struct {
+				body string
+				err  error
+			}
+			response := make(chan result, 1)
+			go func() {
+				resp, err := http.Get("http://" + ln.Addr().String())
+				if err != nil {
+					response <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				response <- result{body: string(body), err: err}
+			}()
+
+			<-started
+			cancel()
+			time.AfterFunc(tt.releaseAfter, func() { close(release) })
+
+			err = <-serveErr
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error: %v; want error: %v", err, tt.wantErr)
+			}
+
+			got := <-response
+			if tt.wantErr {
+				if got.err == nil {
+					t.Errorf("request finished after the shutdown deadline")
+				}
+				return
+			}
+			if got.err != nil || got.body != "done" {
+				t.Errorf("got: %q, %v; want: %q", got.body, got.err, "done")
+			}
+
+			_, err = net.DialTimeout("tcp", ln.Addr().String(), time.Second)
+			if err == nil {
+				t.Errorf("server still accepts connections after shutdown")
+			}
+		})
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	handler := limitBody(16, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Body string `json:"body"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, input)
+	}))
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "Test 1: Within Limit",
+			body: `{"body":"hi"}`,
+			want: http.StatusOK,
+		},
+		{
+			name: "Test 2: Over Limit",
+			body: `{"body":"` + strings.Repeat("a", 100) + `"}`,
+			want: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/chirps", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("got: %v; want: %v", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/spam"
+)
+
+type SpamDecision struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	AuthorID  uuid.UUID  `json:"author_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id"`
+	Body      string     `json:"body"`
+	Verdict   string     `json:"verdict"`
+	Score     int32      `json:"score"`
+	Signals   []string   `json:"signals"`
+}
+
+func (cfg *apiConfig) listSpamDecisions(w http.ResponseWriter, r *http.Request) {
+	verdict := sql.NullString{}
+	switch v := r.URL.Query().Get("verdict"); spam.Verdict(v) {
+	case "":
+	case spam.VerdictAllow, spam.VerdictHold, spam.VerdictReject:
+		verdict = sql.NullString{String: v, Valid: true}
+	default:
+		respondWithError(w, r, http.StatusBadRequest, "Invalid verdict: accepts only allow, hold or reject", nil)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid limit: accepts 1 to 1000", err)
+			return
+		}
+		limit = parsed
+	}
+
+	decisions, err := cfg.db.ListSpamDecisions(r.Context(), database.ListSpamDecisionsParams{
+		Verdict:  verdict,
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Error Reading spam decisions from DB", err)
+		return
+	}
+
+	returnedDecisions := []SpamDecision{}
+	for _, d := range decisions {
+		decision := SpamDecision{
+			ID:        d.ID,
+			CreatedAt: d.CreatedAt,
+			AuthorID:  d.AuthorID,
+			Body:      d.Body,
+			Verdict:   d.Verdict,
+			Score:     d.Score,
+			Signals:   d.Signals,
+		}
+		if d.ChirpID.Valid {
+			decision.ChirpID = &d.ChirpID.UUID
+		}
+		returnedDecisions = append(returnedDecisions, decision)
+	}
+
+	respondWithJSON(w, http.StatusOK, returnedDecisions)
+}
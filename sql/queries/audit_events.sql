@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, actor_id, action, target_type, target_id, ip, user_agent, diff)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor_id)::UUID IS NULL OR actor_id = sqlc.narg(actor_id))
+AND (sqlc.narg(target_id)::TEXT IS NULL OR target_id = sqlc.narg(target_id))
+AND (sqlc.narg(action)::TEXT IS NULL OR action = sqlc.narg(action))
+AND (sqlc.narg(since)::TIMESTAMP IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::TIMESTAMP IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
@@ -10,18 +10,56 @@ VALUES (
 RETURNING *;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps
-ORDER BY created_at ASC;
+-- Chirps from suspended authors are hidden from everyone, and chirps from
+-- shadowbanned authors are only shown to the author themselves.
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= NOW())
+AND (NOT users.shadowbanned OR users.id = sqlc.narg(viewer_id))
+ORDER BY chirps.created_at ASC;
 
 -- name: GetAllChirpsByUser :many
-SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+SELECT chirps.* FROM chirps
+JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = sqlc.arg(user_id) AND chirps.hidden_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= NOW())
+AND (NOT users.shadowbanned OR users.id = sqlc.narg(viewer_id))
+ORDER BY chirps.created_at ASC;
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
 WHERE id = $1 LIMIT 1;
 
+-- name: GetChirpsChangedAt :one
+-- A suspension that runs out changes the lists without any write, so its end
+-- counts as a change too.
+SELECT GREATEST(
+    (SELECT changed_at FROM chirp_changes),
+    (SELECT MAX(suspended_until) FROM users WHERE suspended_until <= NOW())
+)::timestamp AS changed_at;
+
+-- name: GetRecentChirpsByUser :many
+-- Includes hidden chirps so a held chirp cannot simply be posted again.
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id) AND created_at >= sqlc.arg(since)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
 -- name: DeleteChirpByID :exec
 DELETE FROM chirps
 WHERE id = $1;
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = COALESCE(hidden_at, NOW()),
+    hidden_reason = $2
+WHERE id = $1;
+
+-- name: UnhideChirp :exec
+UPDATE chirps
+SET
+    hidden_at = NULL,
+    hidden_reason = NULL
+WHERE id = $1;
@@ -0,0 +1,31 @@
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE key = $1
+LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (key, failures, last_failure_at, locked_until)
+VALUES (
+    sqlc.arg(key),
+    1,
+    sqlc.arg(failed_at),
+    NULL
+)
+ON CONFLICT (key) DO UPDATE
+SET
+    failures = CASE
+        WHEN login_attempts.last_failure_at < sqlc.arg(window_start) THEN 1
+        ELSE login_attempts.failures + 1
+    END,
+    last_failure_at = sqlc.arg(failed_at)
+RETURNING *;
+
+-- name: LockLoginAttempt :exec
+UPDATE login_attempts
+SET
+    locked_until = $2
+WHERE key = $1;
+
+-- name: ResetLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE key = $1;
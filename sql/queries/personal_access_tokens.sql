@@ -0,0 +1,36 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, created_at, updated_at, name, token_prefix, token_hash, scopes, expires_at, user_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetPersonalAccessTokensByPrefix :many
+SELECT * FROM personal_access_tokens
+WHERE $1 = token_prefix;
+
+-- name: GetPersonalAccessTokensByUser :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at ASC;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens
+SET
+    last_used_at = NOW()
+WHERE $1 = id;
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET
+    revoked_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
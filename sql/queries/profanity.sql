@@ -0,0 +1,22 @@
+-- name: ListProfanityWords :many
+SELECT * FROM profanity_words
+ORDER BY locale ASC, word ASC;
+
+-- name: UpsertProfanityWord :one
+INSERT INTO profanity_words (locale, word, action, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (locale, word) DO UPDATE
+SET
+    action = EXCLUDED.action,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteProfanityWord :execrows
+DELETE FROM profanity_words
+WHERE locale = $1 AND word = $2;
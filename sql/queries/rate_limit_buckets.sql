@@ -0,0 +1,21 @@
+-- name: EnsureRateLimitBucket :exec
+INSERT INTO rate_limit_buckets (key)
+VALUES ($1)
+ON CONFLICT (key) DO NOTHING;
+
+-- name: GetRateLimitBucketForUpdate :one
+SELECT * FROM rate_limit_buckets
+WHERE key = $1
+FOR UPDATE;
+
+-- name: UpdateRateLimitBucket :exec
+UPDATE rate_limit_buckets
+SET
+    tokens = $2,
+    updated_at = $3,
+    full_at = $4
+WHERE key = $1;
+
+-- name: DeleteFullRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE full_at <= $1;
@@ -1,23 +1,24 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, expires_at, revoked_at, user_id)
+INSERT INTO refresh_tokens (id, token_prefix, token_hash, created_at, updated_at, expires_at, revoked_at, user_id)
 VALUES (
+    gen_random_uuid(),
     $1,
+    $2,
     NOW(),
     NOW(),
-    $2,
+    $3,
     NULL,
-    $3
+    $4
 )
 RETURNING *;
 
--- name: GetUserFromRefreshToken :one
+-- name: GetRefreshTokensByPrefix :many
 SELECT * FROM refresh_tokens
-WHERE $1 = token
-LIMIT 1;
+WHERE $1 = token_prefix;
 
 -- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET
     revoked_at = $2,
     updated_at = $3
-WHERE $1 = token;
+WHERE $1 = id;
@@ -0,0 +1,51 @@
+-- name: CreateChirpReport :one
+INSERT INTO chirp_reports (id, created_at, chirp_id, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING *;
+
+-- name: CountOpenReportsForChirp :one
+SELECT COUNT(*) FROM chirp_reports
+WHERE chirp_id = $1 AND resolved_at IS NULL;
+
+-- name: GetOpenReportQueue :many
+SELECT
+    chirps.id AS chirp_id,
+    chirps.body,
+    chirps.user_id AS author_id,
+    chirps.hidden_at,
+    COUNT(chirp_reports.id) AS report_count,
+    ARRAY_AGG(DISTINCT chirp_reports.reason)::TEXT[] AS reasons,
+    MIN(chirp_reports.created_at)::TIMESTAMP AS first_reported_at,
+    MAX(chirp_reports.created_at)::TIMESTAMP AS last_reported_at
+FROM chirp_reports
+JOIN chirps ON chirps.id = chirp_reports.chirp_id
+WHERE chirp_reports.resolved_at IS NULL
+GROUP BY chirps.id
+ORDER BY report_count DESC, first_reported_at ASC;
+
+-- name: ResolveReportsForChirp :exec
+UPDATE chirp_reports
+SET
+    resolved_at = NOW()
+WHERE chirp_id = $1 AND resolved_at IS NULL;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, chirp_id, author_id, action, note)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
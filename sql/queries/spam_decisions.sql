@@ -0,0 +1,18 @@
+-- name: CreateSpamDecision :exec
+INSERT INTO spam_decisions (id, created_at, author_id, chirp_id, body, verdict, score, signals)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: ListSpamDecisions :many
+SELECT * FROM spam_decisions
+WHERE (sqlc.narg(verdict)::TEXT IS NULL OR verdict = sqlc.narg(verdict))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
@@ -32,3 +32,40 @@ SET
     is_chirpy_red = TRUE
 WHERE $1 = id
 RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1
+LIMIT 1;
+
+-- name: SetUserRole :one
+UPDATE users
+SET
+    role = $2,
+    updated_at = NOW()
+WHERE $1 = id
+RETURNING *;
+
+-- name: PromoteFirstAdmin :execrows
+UPDATE users
+SET
+    role = 'admin',
+    updated_at = NOW()
+WHERE id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM users WHERE role = 'admin'
+);
+
+-- name: SuspendUser :exec
+UPDATE users
+SET
+    suspended_until = $2,
+    updated_at = NOW()
+WHERE $1 = id;
+
+-- name: SetUserShadowbanned :exec
+UPDATE users
+SET
+    shadowbanned = $2,
+    updated_at = NOW()
+WHERE $1 = id;
@@ -0,0 +1,37 @@
+-- +goose up
+ALTER TABLE refresh_tokens
+ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid(),
+ADD COLUMN token_prefix TEXT,
+ADD COLUMN token_hash TEXT;
+
+-- Existing rows still hold the raw token, so hash them in place to keep
+-- current sessions valid before the raw column is dropped.
+UPDATE refresh_tokens
+SET
+    token_prefix = LEFT(token, 8),
+    token_hash = ENCODE(SHA256(CONVERT_TO(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+DROP COLUMN token;
+
+ALTER TABLE refresh_tokens
+ADD PRIMARY KEY (id),
+ALTER COLUMN token_prefix SET NOT NULL,
+ALTER COLUMN token_hash SET NOT NULL;
+
+CREATE INDEX refresh_tokens_token_prefix_idx ON refresh_tokens (token_prefix);
+
+-- +goose down
+-- Raw tokens cannot be recovered from their hashes, so every session is
+-- invalidated and users have to log in again.
+DELETE FROM refresh_tokens;
+
+DROP INDEX refresh_tokens_token_prefix_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN id,
+DROP COLUMN token_prefix,
+DROP COLUMN token_hash;
+
+ALTER TABLE refresh_tokens
+ADD COLUMN token TEXT PRIMARY KEY;
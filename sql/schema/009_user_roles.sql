@@ -0,0 +1,9 @@
+-- +goose up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user',
+ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- +goose down
+ALTER TABLE users
+DROP CONSTRAINT users_role_check,
+DROP COLUMN role;
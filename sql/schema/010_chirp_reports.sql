@@ -0,0 +1,48 @@
+-- +goose up
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP DEFAULT NULL,
+ADD COLUMN hidden_reason TEXT DEFAULT NULL;
+
+CREATE TABLE chirp_reports(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL,
+    reporter_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP DEFAULT NULL,
+    CONSTRAINT fk_chirp
+    FOREIGN KEY (chirp_id)
+    REFERENCES chirps (id)
+    ON DELETE CASCADE,
+    CONSTRAINT fk_reporter
+    FOREIGN KEY (reporter_id)
+    REFERENCES users (id)
+    ON DELETE CASCADE,
+    CONSTRAINT chirp_reports_reason_check
+    CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other')),
+    CONSTRAINT chirp_reports_one_per_reporter
+    UNIQUE (chirp_id, reporter_id)
+);
+
+-- Moderation actions outlive the chirps and users they refer to, so they are
+-- not foreign keys.
+CREATE TABLE moderation_actions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID NOT NULL,
+    chirp_id UUID NOT NULL,
+    author_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('dismiss', 'hide', 'delete'))
+);
+
+-- +goose down
+DROP TABLE moderation_actions;
+DROP TABLE chirp_reports;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at,
+DROP COLUMN hidden_reason;
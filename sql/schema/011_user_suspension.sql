@@ -0,0 +1,21 @@
+-- +goose up
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP DEFAULT NULL,
+ADD COLUMN shadowbanned BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check
+CHECK (action IN ('dismiss', 'hide', 'delete', 'suspend'));
+
+-- +goose down
+DELETE FROM moderation_actions WHERE action = 'suspend';
+
+ALTER TABLE moderation_actions
+DROP CONSTRAINT moderation_actions_action_check,
+ADD CONSTRAINT moderation_actions_action_check
+CHECK (action IN ('dismiss', 'hide', 'delete'));
+
+ALTER TABLE users
+DROP COLUMN shadowbanned,
+DROP COLUMN suspended_until;
@@ -0,0 +1,30 @@
+-- +goose up
+CREATE TABLE profanity_words(
+    locale TEXT NOT NULL,
+    word TEXT NOT NULL,
+    action TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (locale, word),
+    CONSTRAINT profanity_words_action_check
+    CHECK (action IN ('mask', 'flag', 'reject'))
+);
+
+-- Chirps flagged by the profanity filter are queued for review as reports
+-- without a reporter.
+ALTER TABLE chirp_reports
+ALTER COLUMN reporter_id DROP NOT NULL,
+DROP CONSTRAINT chirp_reports_reason_check,
+ADD CONSTRAINT chirp_reports_reason_check
+CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other', 'filter'));
+
+-- +goose down
+DELETE FROM chirp_reports WHERE reporter_id IS NULL;
+
+ALTER TABLE chirp_reports
+ALTER COLUMN reporter_id SET NOT NULL,
+DROP CONSTRAINT chirp_reports_reason_check,
+ADD CONSTRAINT chirp_reports_reason_check
+CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other'));
+
+DROP TABLE profanity_words;
@@ -0,0 +1,11 @@
+-- +goose up
+-- A bucket without updated_at has just been created and is full.
+CREATE TABLE rate_limit_buckets(
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NULL,
+    full_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose down
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,20 @@
+-- +goose up
+-- Every spam check is kept, including rejected chirps that were never
+-- stored, so the chirp is not a foreign key.
+CREATE TABLE spam_decisions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    author_id UUID NOT NULL,
+    chirp_id UUID DEFAULT NULL,
+    body TEXT NOT NULL,
+    verdict TEXT NOT NULL,
+    score INTEGER NOT NULL,
+    signals TEXT[] NOT NULL DEFAULT '{}',
+    CONSTRAINT spam_decisions_verdict_check
+    CHECK (verdict IN ('allow', 'hold', 'reject'))
+);
+
+CREATE INDEX spam_decisions_created_at_idx ON spam_decisions (created_at);
+
+-- +goose down
+DROP TABLE spam_decisions;
@@ -0,0 +1,40 @@
+-- +goose up
+-- chirp_changes holds a single row recording when the chirp lists last
+-- changed, so list responses can carry Last-Modified. Deleting or hiding a
+-- chirp, or suspending or shadowbanning its author, changes the lists without
+-- moving any listed chirp's updated_at, so triggers keep it current. Every
+-- chirp write updates the row, so concurrent transactions that write chirps
+-- conflict on it and one of them is retried.
+CREATE TABLE chirp_changes(
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    changed_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO chirp_changes (changed_at) VALUES (NOW());
+
+-- Lets the most recent expired suspension be found without a full scan
+CREATE INDEX users_suspended_until_idx ON users (suspended_until);
+
+-- +goose StatementBegin
+CREATE FUNCTION touch_chirp_changes() RETURNS trigger AS $$
+BEGIN
+    UPDATE chirp_changes SET changed_at = clock_timestamp();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_touch_chirp_changes
+AFTER INSERT OR UPDATE OR DELETE ON chirps
+FOR EACH STATEMENT EXECUTE FUNCTION touch_chirp_changes();
+
+CREATE TRIGGER users_touch_chirp_changes
+AFTER UPDATE OF suspended_until, shadowbanned OR DELETE ON users
+FOR EACH STATEMENT EXECUTE FUNCTION touch_chirp_changes();
+
+-- +goose down
+DROP TRIGGER users_touch_chirp_changes ON users;
+DROP TRIGGER chirps_touch_chirp_changes ON chirps;
+DROP FUNCTION touch_chirp_changes;
+DROP INDEX users_suspended_until_idx;
+DROP TABLE chirp_changes;
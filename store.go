@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/mattnickolaus/chirpy/internal/cache"
+	"github.com/mattnickolaus/chirpy/internal/config"
+	"github.com/mattnickolaus/chirpy/internal/metrics"
+	"github.com/mattnickolaus/chirpy/internal/migrate"
+	"github.com/mattnickolaus/chirpy/internal/storage"
+)
+
+// openStore opens the configured backend. db is nil for the in-memory store.
+// A SQLite database is migrated whenever it is opened, Postgres only with
+// database.auto_migrate.
+func openStore(ctx context.Context, dbCfg config.Database, logger *slog.Logger) (store storage.TxStore, db *sql.DB, err error) {
+	switch dbCfg.Driver {
+	case "memory":
+		logger.Warn("Using the in-memory store, nothing is kept on restart")
+		return storage.NewMemoryStore(), nil, nil
+	case "sqlite":
+		db, err = storage.OpenSQLite(ctx, dbCfg.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return storage.NewSQLiteStore(db), db, nil
+	}
+
+	db, err = sql.Open("postgres", dbCfg.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	db.SetMaxOpenConns(dbCfg.MaxOpenConns)
+	db.SetMaxIdleConns(dbCfg.MaxIdleConns)
+	db.SetConnMaxLifetime(dbCfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(dbCfg.ConnMaxIdleTime)
+
+	// Replicas starting together queue up on goose's advisory lock, so each
+	// migration is applied once
+	if dbCfg.AutoMigrate {
+		provider, err := migrate.NewProvider(db)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		results, err := provider.Up(ctx)
+		for _, result := range results {
+			logger.Info("Applied migration", "migration", result.Source.Path, "duration", result.Duration)
+		}
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+	}
+
+	return storage.NewPostgresStore(db), db, nil
+}
+
+// latestMigration is the migration the driver's database must be at for the
+// readiness check, or 0 for the in-memory store, which has no schema.
+func latestMigration(driver string) (int64, error) {
+	switch driver {
+	case "postgres":
+		return migrate.Latest()
+	case "sqlite":
+		migrations, err := storage.SQLiteMigrations()
+		if err != nil {
+			return 0, err
+		}
+		return migrate.LatestIn(migrations)
+	}
+	return 0, nil
+}
+
+// redisKeyPrefix keeps Chirpy's keys apart on a shared Redis server
+const redisKeyPrefix = "chirpy:"
+
+// cacheStore puts the configured cache in front of store's chirp and user
+// lookups. closeCache releases the cache's connections.
+func cacheStore(store storage.TxStore, cacheCfg config.Cache, m *metrics.Metrics) (cached storage.TxStore, closeCache func(), err error) {
+	var c cache.Cache
+	closeCache = func() {}
+	switch cacheCfg.Backend {
+	case "none":
+		return store, closeCache, nil
+	case "redis":
+		redis, err := cache.NewRedis(cacheCfg.RedisURL, redisKeyPrefix)
+		if err != nil {
+			return nil, nil, err
+		}
+		c, closeCache = redis, func() { redis.Close() }
+	default:
+		c = cache.NewLRU(cacheCfg.MaxEntries, cacheCfg.MaxBytes)
+	}
+
+	observe := func(table, result string) {
+		m.CacheLookups.WithLabelValues(table, result).Inc()
+	}
+	return storage.NewCachedStore(store, c, cacheCfg.TTL, observe), closeCache, nil
+}
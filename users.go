@@ -1,13 +1,16 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mattnickolaus/chirpy/internal/auth"
-	"github.com/mattnickolaus/chirpy/internal/database"
+	"github.com/mattnickolaus/chirpy/internal/logging"
+	"github.com/mattnickolaus/chirpy/internal/service"
 )
 
 func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
@@ -21,25 +24,18 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 
 	err := decoder.Decode(&u)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(u.Password)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error hashing password", err)
+	user, err := cfg.service.CreateUser(r.Context(), u.Email, u.Password)
+	validationErr := service.ValidationError{}
+	if errors.As(err, &validationErr) {
+		respondWithError(w, r, http.StatusBadRequest, validationErr.Error(), nil)
 		return
 	}
-	convertedHashedPassword := sql.NullString{String: hashedPassword, Valid: true}
-
-	userParams := database.CreateUserParams{
-		Email:          u.Email,
-		HashedPassword: convertedHashedPassword,
-	}
-
-	user, err := cfg.db.CreateUser(r.Context(), userParams)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error Writing to database", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
 		return
 	}
 
@@ -49,22 +45,15 @@ func (cfg *apiConfig) createUser(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:   user.UpdatedAt.Time,
 		Email:       user.Email,
 		IsChirpyRed: user.IsChirpyRed.Bool,
+		Role:        user.Role,
 	}
 
 	respondWithJSON(w, http.StatusCreated, returnUser)
 }
 
 func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
-	tokenString, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(tokenString, cfg.secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Unauthorized: Token Invalid:", err)
-		return
-	}
+	principal, _ := requestPrincipal(r)
+	userID := principal.UserID
 
 	type updateUserInput struct {
 		Password string `json:"password"`
@@ -73,29 +62,34 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 	u := updateUserInput{}
 	decoder := json.NewDecoder(r.Body)
 
-	err = decoder.Decode(&u)
+	err := decoder.Decode(&u)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(u.Password)
+	update, err := cfg.service.UpdateUser(r.Context(), userID, u.Email, u.Password)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error hashing password", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Error Writing to database", err)
 		return
 	}
-	convertedHashedPassword := sql.NullString{String: hashedPassword, Valid: true}
+	currentUser, updatedUser := update.Before, update.After
 
-	updateUserParams := database.UpdateUserParams{
-		ID:             userID,
-		Email:          u.Email,
-		HashedPassword: convertedHashedPassword,
+	diff := auditDiff{}
+	if updatedUser.Email != currentUser.Email {
+		diff["email"] = auditChange{From: currentUser.Email, To: updatedUser.Email}
 	}
-
-	updatedUser, err := cfg.db.UpdateUser(r.Context(), updateUserParams)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error Writing to database", err)
-		return
+	if update.PasswordChanged {
+		diff["password"] = auditChange{To: "changed"}
+	}
+	if len(diff) > 0 {
+		cfg.recordAudit(r, auditEvent{
+			ActorID:    &userID,
+			Action:     auditUserUpdated,
+			TargetType: auditTargetUser,
+			TargetID:   userID.String(),
+			Diff:       diff,
+		})
 	}
 
 	returnedUpdatedUser := User{
@@ -104,6 +98,7 @@ func (cfg *apiConfig) updateUser(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:   updatedUser.UpdatedAt.Time,
 		Email:       updatedUser.Email,
 		IsChirpyRed: updatedUser.IsChirpyRed.Bool,
+		Role:        updatedUser.Role,
 	}
 
 	respondWithJSON(w, http.StatusOK, returnedUpdatedUser)
@@ -120,66 +115,121 @@ func (cfg *apiConfig) login(w http.ResponseWriter, r *http.Request) {
 
 	err := decoder.Decode(&u)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+
+	ip := cfg.clientIP(r)
+	retryAfter, err := cfg.loginGuard.Check(r.Context(), u.Email, ip)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to check login attempts", err)
+		return
+	}
+	if retryAfter > 0 {
+		cfg.metrics.Logins.WithLabelValues("blocked").Inc()
+		respondWithTooManyRequests(w, r, retryAfter, "Too many failed login attempts, try again later")
 		return
 	}
 
 	user, err := cfg.db.GetUserByUsername(r.Context(), u.Email)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		cfg.recordLoginFailure(r, u.Email, ip, nil)
+		respondWithError(w, r, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
 	matched, err := auth.CheckPasswordHash(u.Password, user.HashedPassword.String)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		cfg.recordLoginFailure(r, u.Email, ip, &user.ID)
+		respondWithError(w, r, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 	if !matched {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
+		cfg.recordLoginFailure(r, u.Email, ip, &user.ID)
+		respondWithError(w, r, http.StatusUnauthorized, "Incorrect email or password", nil)
 		return
 	}
 
-	// NOTE: Hard Coded 1 hour JWT expiration time
-	expiresInHour := time.Second * time.Duration(3600)
-
-	tokenString, err := auth.MakeJWT(user.ID, cfg.secret, expiresInHour)
+	err = cfg.loginGuard.RecordSuccess(r.Context(), u.Email)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to generate Web Token", err)
+		logging.FromContext(r.Context()).Error("Error resetting login attempts", "error", err)
+	}
+
+	session, err := cfg.service.StartSession(r.Context(), user.ID)
+	suspended := service.SuspendedError{}
+	if errors.As(err, &suspended) {
+		cfg.metrics.Logins.WithLabelValues("suspended").Inc()
+		cfg.recordAudit(r, auditEvent{
+			ActorID:    &user.ID,
+			Action:     auditLoginRefused,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       auditDiff{"suspended_until": {To: suspended.Until}},
+		})
+		respondWithError(w, r, http.StatusForbidden, "Forbidden: "+suspended.Error(), nil)
 		return
 	}
-
-	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to generate Refresh Token", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Unable to start session", err)
 		return
 	}
-	// NOTE: Hard Coded 60 days from now expiration for Refresh Token
-	expiresSixtyDaysFromToday := sql.NullTime{
-		Time:  time.Now().Add(60 * 24 * time.Hour),
-		Valid: true,
-	}
-	refreshTokenParams := database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		ExpiresAt: expiresSixtyDaysFromToday,
-		UserID:    user.ID,
-	}
+	user = session.User
 
-	writtenRefreshToken, err := cfg.db.CreateRefreshToken(r.Context(), refreshTokenParams)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Unable to write Refresh Token to DB", err)
-		return
-	}
+	cfg.metrics.Logins.WithLabelValues("success").Inc()
+	cfg.recordAudit(r, auditEvent{
+		ActorID:    &user.ID,
+		Action:     auditLoginSucceeded,
+		TargetType: auditTargetUser,
+		TargetID:   user.ID.String(),
+	})
 
 	returnUser := User{
 		ID:           user.ID,
 		CreatedAt:    user.CreatedAt.Time,
 		UpdatedAt:    user.UpdatedAt.Time,
 		Email:        user.Email,
-		Token:        tokenString,
-		RefreshToken: writtenRefreshToken.Token,
+		Token:        session.AccessToken,
+		RefreshToken: session.RefreshToken,
 		IsChirpyRed:  user.IsChirpyRed.Bool,
+		Role:         user.Role,
 	}
 
 	respondWithJSON(w, http.StatusOK, returnUser)
 }
+
+// recordLoginFailure audits and counts a failed login and, the first time an
+// existing account crosses the lockout threshold, notifies its owner in the
+// background. userID is nil when no account has that email.
+func (cfg *apiConfig) recordLoginFailure(r *http.Request, email, ip string, userID *uuid.UUID) {
+	event := auditEvent{
+		Action:     auditLoginFailed,
+		TargetType: auditTargetEmail,
+		TargetID:   email,
+	}
+	if userID != nil {
+		event.TargetType = auditTargetUser
+		event.TargetID = userID.String()
+	}
+	cfg.metrics.Logins.WithLabelValues("failure").Inc()
+	cfg.recordAudit(r, event)
+
+	result, err := cfg.loginGuard.RecordFailure(r.Context(), email, ip)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Error recording failed login", "error", err)
+		return
+	}
+
+	if !result.AccountLockedOut || userID == nil || cfg.lockoutNotifier == nil {
+		return
+	}
+	logger := logging.FromContext(r.Context())
+	cfg.background.Go(func() {
+		notifyCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := cfg.lockoutNotifier.NotifyLockout(notifyCtx, email, result.AccountLockedUntil)
+		if err != nil {
+			logger.Error("Error sending lockout notification", "error", err)
+		}
+	})
+}